@@ -0,0 +1,69 @@
+package hdwallet
+
+import (
+	errors "errors"
+	fmt "fmt"
+	strconv "strconv"
+	strings "strings"
+)
+
+// CoinType is the BIP-44 coin type of the wallet paths. The chain has no registered SLIP-44 number, the value
+// only has to stay the same for wallets to be recoverable.
+const CoinType uint32 = 8437
+
+var ErrInvalidDerivationPath = errors.New("invalid derivation path")
+
+// DerivationPath is the list of child indexes from the master key, hardened indexes include HardenedOffset.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path such as m/44'/8437'/0'/0/1. Hardened indexes are marked with ' or h.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	components := strings.Split(strings.TrimSpace(path), "/")
+	if components[0] != "m" {
+		return nil, fmt.Errorf("%w: %q must start with m", ErrInvalidDerivationPath, path)
+	}
+
+	derivationPath := DerivationPath{}
+	for _, component := range components[1:] {
+		hardened := strings.HasSuffix(component, "'") || strings.HasSuffix(component, "h") || strings.HasSuffix(component, "H")
+		if hardened {
+			component = component[:len(component)-1]
+		}
+
+		index, err := strconv.ParseUint(component, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("%w: invalid index %q in %q", ErrInvalidDerivationPath, component, path)
+		}
+
+		if hardened {
+			index += uint64(HardenedOffset)
+		}
+		derivationPath = append(derivationPath, uint32(index))
+	}
+
+	return derivationPath, nil
+}
+
+func (path DerivationPath) String() string {
+	var builder strings.Builder
+	builder.WriteString("m")
+	for _, index := range path {
+		if index >= HardenedOffset {
+			fmt.Fprintf(&builder, "/%d'", index-HardenedOffset)
+		} else {
+			fmt.Fprintf(&builder, "/%d", index)
+		}
+	}
+	return builder.String()
+}
+
+// Child returns the path of the child at index without sharing the backing array of path.
+func (path DerivationPath) Child(index uint32) DerivationPath {
+	return append(append(DerivationPath{}, path...), index)
+}
+
+// AccountPath is the BIP-44 path of the external chain of an account, m/44'/coin'/account'/0. The addresses of
+// the account are its non hardened children.
+func AccountPath(account uint32) DerivationPath {
+	return DerivationPath{44 + HardenedOffset, CoinType + HardenedOffset, account + HardenedOffset, 0}
+}
@@ -0,0 +1,58 @@
+package hdwallet
+
+import (
+	errors "errors"
+	reflect "reflect"
+	testing "testing"
+)
+
+func TestParseDerivationPathRoundTrip(t *testing.T) {
+	tests := []struct {
+		path      string
+		expected  DerivationPath
+		canonical string
+	}{
+		{path: "m", expected: DerivationPath{}, canonical: "m"},
+		{path: "m/0", expected: DerivationPath{0}, canonical: "m/0"},
+		{path: "m/0'/1", expected: DerivationPath{HardenedOffset, 1}, canonical: "m/0'/1"},
+		{path: "m/0h/1H", expected: DerivationPath{HardenedOffset, 1 + HardenedOffset}, canonical: "m/0'/1'"},
+		{path: " m/44'/8437'/0'/0/1 ", expected: AccountPath(0).Child(1), canonical: "m/44'/8437'/0'/0/1"},
+		{path: "m/2147483647'/2147483647", expected: DerivationPath{0xffffffff, HardenedOffset - 1}, canonical: "m/2147483647'/2147483647"},
+	}
+
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Fatalf("%q: %v", test.path, err)
+		}
+		if !reflect.DeepEqual(path, test.expected) {
+			t.Fatalf("%q parsed to %v, want %v", test.path, path, test.expected)
+		}
+		if path.String() != test.canonical {
+			t.Fatalf("%q prints as %q, want %q", test.path, path.String(), test.canonical)
+		}
+
+		reparsed, err := ParseDerivationPath(path.String())
+		if err != nil || !reflect.DeepEqual(reparsed, path) {
+			t.Fatalf("%q does not round trip: %v, %v", path.String(), reparsed, err)
+		}
+	}
+}
+
+func TestParseDerivationPathRejectsMalformedPaths(t *testing.T) {
+	for _, path := range []string{"", "M/0", "44'/0", "m/", "m//1", "m/-1", "m/+1", "m/x", "m/1''", "m/0x1", "m/2147483648", "m/2147483648'", "m/1 /2"} {
+		if _, err := ParseDerivationPath(path); !errors.Is(err, ErrInvalidDerivationPath) {
+			t.Fatalf("%q: got %v, want %v", path, err, ErrInvalidDerivationPath)
+		}
+	}
+}
+
+func TestChildDoesNotShareTheParentPath(t *testing.T) {
+	account := make(DerivationPath, 0, 8)
+	account = append(account, AccountPath(0)...)
+
+	first, second := account.Child(0), account.Child(1)
+	if first[len(first)-1] != 0 || second[len(second)-1] != 1 {
+		t.Fatalf("children share their backing array: %v, %v", first, second)
+	}
+}
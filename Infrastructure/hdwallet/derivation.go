@@ -0,0 +1,112 @@
+package hdwallet
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	hmac "crypto/hmac"
+	sha512 "crypto/sha512"
+	binary "encoding/binary"
+	errors "errors"
+	big "math/big"
+)
+
+// HardenedOffset is added to a child index for hardened derivation, which needs the parent private key.
+const HardenedOffset uint32 = 0x80000000
+
+// masterKeySalt is the HMAC key SLIP-10 assigns to the NIST P-256 curve.
+var masterKeySalt = []byte("Nist256p1 seed")
+
+var ErrInvalidSeed = errors.New("seed must be 16 to 64 bytes")
+
+// ExtendedKey is a private key with the chain code its children are derived from. Derivation follows SLIP-10,
+// which carries BIP-32 over to P-256: the arithmetic is the same, but a derived scalar that falls outside the
+// curve order is derived again instead of skipping the index.
+type ExtendedKey struct {
+	key       *big.Int
+	chainCode []byte
+	Depth     uint8
+	Index     uint32
+}
+
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+
+	n := elliptic.P256().Params().N
+	data := seed
+	for {
+		digest := hmacSha512(masterKeySalt, data)
+		key := new(big.Int).SetBytes(digest[:32])
+		if key.Sign() != 0 && key.Cmp(n) < 0 {
+			return &ExtendedKey{key: key, chainCode: digest[32:]}, nil
+		}
+		data = digest
+	}
+}
+
+// Child derives the child at index, indexes from HardenedOffset on are hardened.
+func (extendedKey *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if extendedKey.Depth == 0xff {
+		return nil, errors.New("derivation depth exceeds 255")
+	}
+
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, extendedKey.key.FillBytes(make([]byte, 32))...)
+	} else {
+		data = primitives.MarshalPublicKey(&extendedKey.PrivateKey().PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	for {
+		digest := hmacSha512(extendedKey.chainCode, data)
+		tweak := new(big.Int).SetBytes(digest[:32])
+
+		if tweak.Cmp(n) < 0 {
+			key := new(big.Int).Add(tweak, extendedKey.key)
+			key.Mod(key, n)
+			if key.Sign() != 0 {
+				return &ExtendedKey{key: key, chainCode: digest[32:], Depth: extendedKey.Depth + 1, Index: index}, nil
+			}
+		}
+
+		data = binary.BigEndian.AppendUint32(append([]byte{0x01}, digest[32:]...), index)
+	}
+}
+
+// Derive follows the path from this key, which is taken to be the key at m.
+func (extendedKey *ExtendedKey) Derive(path DerivationPath) (*ExtendedKey, error) {
+	derived := extendedKey
+	for _, index := range path {
+		var err error
+		if derived, err = derived.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return derived, nil
+}
+
+func (extendedKey *ExtendedKey) PrivateKey() *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	x, y := curve.ScalarBaseMult(extendedKey.key.FillBytes(make([]byte, 32)))
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         new(big.Int).Set(extendedKey.key),
+	}
+}
+
+func (extendedKey *ExtendedKey) ChainCode() []byte {
+	return append([]byte(nil), extendedKey.chainCode...)
+}
+
+func hmacSha512(key []byte, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
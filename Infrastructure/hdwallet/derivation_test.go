@@ -0,0 +1,101 @@
+package hdwallet
+
+import (
+	hex "encoding/hex"
+	testing "testing"
+)
+
+type derivationVector struct {
+	path       string
+	chainCode  string
+	privateKey string
+}
+
+func testDerivationVectors(t *testing.T, seedHex string, vectors []derivationVector) {
+	t.Helper()
+
+	seed, _ := hex.DecodeString(seedHex)
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vector := range vectors {
+		path, err := ParseDerivationPath(vector.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		derived, err := master.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if chainCode := hex.EncodeToString(derived.ChainCode()); chainCode != vector.chainCode {
+			t.Fatalf("%s chain code\n got: %s\nwant: %s", vector.path, chainCode, vector.chainCode)
+		}
+		if privateKey := hex.EncodeToString(derived.PrivateKey().D.FillBytes(make([]byte, 32))); privateKey != vector.privateKey {
+			t.Fatalf("%s private key\n got: %s\nwant: %s", vector.path, privateKey, vector.privateKey)
+		}
+		if int(derived.Depth) != len(path) {
+			t.Fatalf("%s has depth %d", vector.path, derived.Depth)
+		}
+	}
+}
+
+// SLIP-10 test vector 1 for nist256p1.
+func TestSlip10Vector1(t *testing.T) {
+	testDerivationVectors(t, "000102030405060708090a0b0c0d0e0f", []derivationVector{
+		{
+			path:       "m",
+			chainCode:  "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea",
+			privateKey: "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2",
+		},
+		{
+			path:       "m/0'",
+			chainCode:  "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11",
+			privateKey: "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c",
+		},
+		{
+			path:       "m/0'/1",
+			chainCode:  "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c",
+			privateKey: "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129",
+		},
+	})
+}
+
+// SLIP-10 derivation retry vector for nist256p1: the first child of m/28578' falls outside the curve order and
+// has to be derived again from the chain code.
+func TestSlip10DerivationRetry(t *testing.T) {
+	testDerivationVectors(t, "000102030405060708090a0b0c0d0e0f", []derivationVector{
+		{
+			path:       "m/28578'",
+			chainCode:  "e94c8ebe30c2250a14713212f6449b20f3329105ea15b652ca5bdfc68f6c65c2",
+			privateKey: "06f0db126f023755d0b8d86d4591718a5210dd8d024e3e14b6159d63f53aa669",
+		},
+		{
+			path:       "m/28578'/33941",
+			chainCode:  "9e87fe95031f14736774cd82f25fd885065cb7c358c1edf813c72af535e83071",
+			privateKey: "092154eed4af83e078ff9b84322015aefe5769e31270f62c3f66c33888335f3a",
+		},
+	})
+}
+
+// SLIP-10 seed retry vector for nist256p1: the first master key candidate is invalid.
+func TestSlip10SeedRetry(t *testing.T) {
+	testDerivationVectors(t, "a7305bc8df8d0951f0cb224c0e95d7707cbdf2c6ce7e8d481fec69c7ff5e9446", []derivationVector{
+		{
+			path:       "m",
+			chainCode:  "7762f9729fed06121fd13f326884c82f59aa95c57ac492ce8c9654e60efd130c",
+			privateKey: "3b8c18469a4634517d6d0b65448f8e6c62091b45540a1743c5846be55d47d88f",
+		},
+	})
+}
+
+func TestNewMasterKeyRejectsSeedsOutsideTheBip32Range(t *testing.T) {
+	for _, length := range []int{0, 15, 65} {
+		if _, err := NewMasterKey(make([]byte, length)); err != ErrInvalidSeed {
+			t.Fatalf("a %d byte seed returned %v, want %v", length, err, ErrInvalidSeed)
+		}
+	}
+}
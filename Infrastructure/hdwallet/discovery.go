@@ -0,0 +1,62 @@
+package hdwallet
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	ecdsa "crypto/ecdsa"
+)
+
+// DefaultGapLimit is the number of consecutive unused addresses after which discovery stops, as in BIP-44.
+const DefaultGapLimit = 20
+
+// DerivedAddress is an address of an account together with where it was derived.
+type DerivedAddress struct {
+	Index     uint32
+	Path      DerivationPath
+	Address   string
+	PublicKey *ecdsa.PublicKey
+}
+
+// DeriveAddress derives the address at index below the account path.
+func DeriveAddress(master *ExtendedKey, accountPath DerivationPath, index uint32) (DerivedAddress, error) {
+	path := accountPath.Child(index)
+	derived, err := master.Derive(path)
+	if err != nil {
+		return DerivedAddress{}, err
+	}
+
+	publicKey := &derived.PrivateKey().PublicKey
+	return DerivedAddress{
+		Index:     index,
+		Path:      path,
+		Address:   primitives.PublicKeyToAddress(publicKey),
+		PublicKey: publicKey,
+	}, nil
+}
+
+// DiscoverAddresses scans the addresses of the account from index 0 on until gapLimit consecutive ones are
+// unused, and returns every address up to the last used one. Nothing is returned for an account never used.
+func DiscoverAddresses(master *ExtendedKey, accountPath DerivationPath, gapLimit int, used func(address string) (bool, error)) ([]DerivedAddress, error) {
+	if gapLimit <= 0 {
+		gapLimit = DefaultGapLimit
+	}
+
+	scanned := []DerivedAddress{}
+	lastUsed := -1
+	for index := uint32(0); len(scanned)-lastUsed <= gapLimit && index < HardenedOffset; index++ {
+		address, err := DeriveAddress(master, accountPath, index)
+		if err != nil {
+			return nil, err
+		}
+		scanned = append(scanned, address)
+
+		isUsed, err := used(address.Address)
+		if err != nil {
+			return nil, err
+		}
+		if isUsed {
+			lastUsed = len(scanned) - 1
+		}
+	}
+
+	return scanned[:lastUsed+1], nil
+}
@@ -0,0 +1,94 @@
+package hdwallet
+
+import (
+	errors "errors"
+	testing "testing"
+)
+
+func newTestMasterKey(t *testing.T) *ExtendedKey {
+	t.Helper()
+
+	seed, err := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master
+}
+
+// usedAt marks the addresses of the account at the indexes as used and counts the lookups.
+func usedAt(t *testing.T, master *ExtendedKey, indexes ...uint32) (func(address string) (bool, error), *int) {
+	t.Helper()
+
+	used := map[string]bool{}
+	for _, index := range indexes {
+		address, err := DeriveAddress(master, AccountPath(0), index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		used[address.Address] = true
+	}
+
+	lookups := 0
+	return func(address string) (bool, error) {
+		lookups++
+		return used[address], nil
+	}, &lookups
+}
+
+func TestDiscoverAddressesStopsAtTheGapLimit(t *testing.T) {
+	master := newTestMasterKey(t)
+
+	tests := []struct {
+		name       string
+		used       []uint32
+		gapLimit   int
+		discovered int
+		lookups    int
+	}{
+		{name: "never used", gapLimit: 3, discovered: 0, lookups: 3},
+		{name: "used within the gap", used: []uint32{0, 3}, gapLimit: 3, discovered: 4, lookups: 7},
+		{name: "used right after the gap", used: []uint32{0, 3}, gapLimit: 2, discovered: 1, lookups: 3},
+		{name: "first used at the gap limit", used: []uint32{2}, gapLimit: 2, discovered: 0, lookups: 2},
+		{name: "first used before the gap limit", used: []uint32{2}, gapLimit: 3, discovered: 3, lookups: 6},
+		{name: "default gap limit", used: []uint32{DefaultGapLimit - 1}, discovered: DefaultGapLimit, lookups: 2 * DefaultGapLimit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			used, lookups := usedAt(t, master, test.used...)
+
+			discovered, err := DiscoverAddresses(master, AccountPath(0), test.gapLimit, used)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(discovered) != test.discovered || *lookups != test.lookups {
+				t.Fatalf("discovered %d addresses in %d lookups, want %d in %d", len(discovered), *lookups, test.discovered, test.lookups)
+			}
+
+			for index, address := range discovered {
+				expected, err := DeriveAddress(master, AccountPath(0), uint32(index))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if address.Index != uint32(index) || address.Address != expected.Address || address.Path.String() != expected.Path.String() {
+					t.Fatalf("address %d is %+v, want %+v", index, address, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverAddressesReturnsLookupErrors(t *testing.T) {
+	lookupFailed := errors.New("lookup failed")
+
+	_, err := DiscoverAddresses(newTestMasterKey(t), AccountPath(0), 3, func(address string) (bool, error) {
+		return false, lookupFailed
+	})
+	if !errors.Is(err, lookupFailed) {
+		t.Fatalf("got %v, want %v", err, lookupFailed)
+	}
+}
@@ -0,0 +1,130 @@
+package hdwallet
+
+import (
+	rand "crypto/rand"
+	sha256 "crypto/sha256"
+	sha512 "crypto/sha512"
+	errors "errors"
+	fmt "fmt"
+	big "math/big"
+	strings "strings"
+
+	pbkdf2 "golang.org/x/crypto/pbkdf2"
+	norm "golang.org/x/text/unicode/norm"
+)
+
+const (
+	MinEntropyBits = 128
+	MaxEntropyBits = 256
+	// DefaultEntropyBits gives 24 word mnemonics.
+	DefaultEntropyBits = 256
+
+	seedIterations = 2048
+	bitsPerWord    = 11
+)
+
+var (
+	ErrInvalidEntropy  = errors.New("entropy must be 128 to 256 bits in steps of 32")
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+)
+
+var wordIndexes = func() map[string]int {
+	indexes := make(map[string]int, len(englishWordlist))
+	for index, word := range englishWordlist {
+		indexes[word] = index
+	}
+	return indexes
+}()
+
+// NewMnemonic generates a mnemonic sentence of random entropy as described in BIP-39.
+func NewMnemonic(entropyBits int) (string, error) {
+	if err := validateEntropyBits(entropyBits); err != nil {
+		return "", err
+	}
+
+	entropy := make([]byte, entropyBits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic encodes the entropy followed by the first bits of its SHA-256 as one word per 11 bits.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if err := validateEntropyBits(len(entropy) * 8); err != nil {
+		return "", err
+	}
+
+	checksumBits := len(entropy) * 8 / 32
+	checksum := sha256.Sum256(entropy)
+
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, uint(checksumBits))
+	bits.Or(bits, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+
+	wordCount := (len(entropy)*8 + checksumBits) / bitsPerWord
+	words := make([]string, wordCount)
+	mask := big.NewInt(1<<bitsPerWord - 1)
+	for index := wordCount - 1; index >= 0; index-- {
+		words[index] = englishWordlist[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, bitsPerWord)
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a mnemonic sentence and verifies its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, fmt.Errorf("%w: %d words, expected 12, 15, 18, 21 or 24", ErrInvalidMnemonic, len(words))
+	}
+
+	bits := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndexes[word]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		bits.Lsh(bits, bitsPerWord)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+
+	checksumBits := len(words) * bitsPerWord / 33
+	entropyBytes := checksumBits * 4
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1)).Int64()
+	entropy := new(big.Int).Rsh(bits, uint(checksumBits)).FillBytes(make([]byte, entropyBytes))
+
+	expected := sha256.Sum256(entropy)
+	if int64(expected[0]>>(8-checksumBits)) != checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+	}
+
+	return entropy, nil
+}
+
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed validates the mnemonic and stretches it with the optional passphrase into the 64 byte seed of
+// BIP-39. Different passphrases give unrelated wallets from the same mnemonic.
+func MnemonicToSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	sentence := norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " "))
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+
+	return pbkdf2.Key([]byte(sentence), []byte(salt), seedIterations, 64, sha512.New), nil
+}
+
+func validateEntropyBits(entropyBits int) error {
+	if entropyBits < MinEntropyBits || entropyBits > MaxEntropyBits || entropyBits%32 != 0 {
+		return ErrInvalidEntropy
+	}
+	return nil
+}
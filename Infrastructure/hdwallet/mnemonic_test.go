@@ -0,0 +1,107 @@
+package hdwallet
+
+import (
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	sort "sort"
+	strings "strings"
+	testing "testing"
+)
+
+// BIP-39 vectors of the reference implementation, all seeds use the passphrase "TREZOR".
+var mnemonicVectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		entropy:  "00000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		entropy:  "80808080808080808080808080808080",
+		mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		seed:     "d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		seed:     "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		entropy:  "0000000000000000000000000000000000000000000000000000000000000000",
+		mnemonic: strings.Repeat("abandon ", 23) + "art",
+		seed:     "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		mnemonic: strings.Repeat("zoo ", 23) + "vote",
+		seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+	},
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	for _, vector := range mnemonicVectors {
+		entropy, _ := hex.DecodeString(vector.entropy)
+
+		mnemonic, err := EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != vector.mnemonic {
+			t.Fatalf("entropy %s\n got: %s\nwant: %s", vector.entropy, mnemonic, vector.mnemonic)
+		}
+
+		decoded, err := MnemonicToEntropy(vector.mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(decoded) != vector.entropy {
+			t.Fatalf("mnemonic %q decodes to %x, want %s", vector.mnemonic, decoded, vector.entropy)
+		}
+
+		seed, err := MnemonicToSeed(vector.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(seed) != vector.seed {
+			t.Fatalf("mnemonic %q\n got seed: %x\nwant seed: %s", vector.mnemonic, seed, vector.seed)
+		}
+	}
+}
+
+func TestMnemonicToEntropyRejectsInvalidMnemonics(t *testing.T) {
+	tests := map[string]string{
+		"bad checksum":  strings.Repeat("abandon ", 12),
+		"unknown word":  strings.Repeat("abandon ", 11) + "bitshare",
+		"too few words": strings.Repeat("abandon ", 8) + "about",
+		"odd length":    strings.Repeat("abandon ", 12) + "about",
+	}
+
+	for name, mnemonic := range tests {
+		if _, err := MnemonicToEntropy(mnemonic); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Fatalf("%s: got %v, want %v", name, err, ErrInvalidMnemonic)
+		}
+	}
+}
+
+func TestEnglishWordlistMatchesTheBip39List(t *testing.T) {
+	if len(englishWordlist) != 2048 {
+		t.Fatalf("the wordlist has %d words, want 2048", len(englishWordlist))
+	}
+	if !sort.StringsAreSorted(englishWordlist) {
+		t.Fatal("the wordlist is not sorted")
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(englishWordlist, "\n") + "\n"))
+	if encoded := hex.EncodeToString(hash[:]); encoded != "2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda" {
+		t.Fatalf("the wordlist hashes to %s, not to the published BIP-39 list", encoded)
+	}
+}
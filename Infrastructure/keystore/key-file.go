@@ -0,0 +1,273 @@
+package keystore
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	aes "crypto/aes"
+	cipher "crypto/cipher"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	hex "encoding/hex"
+	errors "errors"
+	fmt "fmt"
+	big "math/big"
+
+	uuid "github.com/google/uuid"
+	scrypt "golang.org/x/crypto/scrypt"
+)
+
+const (
+	KeyFileVersion = 3
+	CipherName     = "aes-256-gcm"
+	KdfName        = "scrypt"
+
+	// StandardScryptN and StandardScryptP are the scrypt costs of the Ethereum keystore, about a second and
+	// 256MB per key. LightScryptN and LightScryptP take a few milliseconds and 4MB.
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	LightScryptN    = 1 << 12
+	LightScryptP    = 6
+
+	scryptR     = 8
+	scryptDkLen = 32
+	// maxScryptN bounds the work a key file can ask for, a forged file must not tie up the node.
+	maxScryptN = 1 << 20
+	// maxConcurrentScrypt bounds the scrypt runs at a time. Each one holds 128 * r * n bytes, 256MB at the
+	// standard cost, so parallel unlock requests queue instead of exhausting the node's memory.
+	maxConcurrentScrypt = 2
+)
+
+var scryptSlots = make(chan struct{}, maxConcurrentScrypt)
+
+// Kinds of key files. A private key file seals a private key, a seed file the seed of an HD wallet and a
+// derived key file only names a seed file and the path of the key below it, it holds nothing secret.
+const (
+	KindPrivateKey = ""
+	KindSeed       = "seed"
+	KindDerived    = "derived"
+)
+
+var (
+	ErrInvalidPassphrase = errors.New("could not decrypt the key with the given passphrase")
+	ErrInvalidKeyFile    = errors.New("invalid key file")
+	ErrInvalidPrivateKey = errors.New("private key is not a valid P-256 scalar")
+)
+
+// KeyFile is a private key encrypted at rest, laid out like the version 3 keystore files of Ethereum. The key
+// is sealed with AES-256-GCM under a scrypt derived key. GCM authenticates the ciphertext, so no separate MAC
+// is stored, and the kind and address are authenticated with it so a key file cannot be relabelled.
+type KeyFile struct {
+	Id      string     `json:"id"`
+	Kind    string     `json:"kind,omitempty"`
+	Address string     `json:"address"`
+	SeedId  string     `json:"seedId,omitempty"`
+	Path    string     `json:"path,omitempty"`
+	Version int        `json:"version"`
+	Crypto  *KeyCrypto `json:"crypto,omitempty"`
+}
+
+type KeyCrypto struct {
+	Cipher       string       `json:"cipher"`
+	CipherText   string       `json:"ciphertext"`
+	CipherParams CipherParams `json:"cipherparams"`
+	Kdf          string       `json:"kdf"`
+	KdfParams    ScryptParams `json:"kdfparams"`
+}
+
+type CipherParams struct {
+	Nonce string `json:"nonce"`
+}
+
+type ScryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DkLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// EncryptKey seals the private key with the passphrase in a new key file with a random id.
+func EncryptKey(privateKey *ecdsa.PrivateKey, passphrase string, scryptN, scryptP int) (*KeyFile, error) {
+	if privateKey == nil || privateKey.D == nil {
+		return nil, errors.New("missing private key")
+	}
+
+	keyFile := &KeyFile{
+		Id:      uuid.NewString(),
+		Kind:    KindPrivateKey,
+		Address: primitives.PublicKeyToAddress(&privateKey.PublicKey),
+		Version: KeyFileVersion,
+	}
+
+	keyCrypto, err := sealKeyCrypto(privateKey.D.FillBytes(make([]byte, 32)), keyFile.additionalData(), passphrase, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	keyFile.Crypto = keyCrypto
+
+	return keyFile, nil
+}
+
+// DecryptKey opens the key file with the passphrase. A wrong passphrase and a tampered file both give
+// ErrInvalidPassphrase, GCM cannot tell them apart.
+func DecryptKey(keyFile *KeyFile, passphrase string) (*ecdsa.PrivateKey, error) {
+	if keyFile.Kind != KindPrivateKey {
+		return nil, fmt.Errorf("%w: %s key files hold no private key", ErrInvalidKeyFile, keyFile.Kind)
+	}
+
+	plainText, err := openKeyCrypto(keyFile, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := privateKeyFromBytes(plainText)
+	if err != nil || len(plainText) != 32 {
+		return nil, fmt.Errorf("%w: the sealed key is not a 32 byte scalar", ErrInvalidKeyFile)
+	}
+
+	if primitives.PublicKeyToAddress(&privateKey.PublicKey) != keyFile.Address {
+		return nil, fmt.Errorf("%w: the key does not belong to address %s", ErrInvalidKeyFile, keyFile.Address)
+	}
+	return privateKey, nil
+}
+
+// EncryptSeed seals the seed of an HD wallet with the passphrase in a new seed file with a random id.
+func EncryptSeed(seed []byte, passphrase string, scryptN, scryptP int) (*KeyFile, error) {
+	keyFile := &KeyFile{
+		Id:      uuid.NewString(),
+		Kind:    KindSeed,
+		Version: KeyFileVersion,
+	}
+
+	keyCrypto, err := sealKeyCrypto(seed, keyFile.additionalData(), passphrase, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	keyFile.Crypto = keyCrypto
+
+	return keyFile, nil
+}
+
+// DecryptSeed opens the seed file with the passphrase.
+func DecryptSeed(keyFile *KeyFile, passphrase string) ([]byte, error) {
+	if keyFile.Kind != KindSeed {
+		return nil, fmt.Errorf("%w: %s is not a seed file", ErrInvalidKeyFile, keyFile.Id)
+	}
+
+	return openKeyCrypto(keyFile, passphrase)
+}
+
+func sealKeyCrypto(plainText []byte, additionalData []byte, passphrase string, scryptN, scryptP int) (*KeyCrypto, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	params := ScryptParams{N: scryptN, R: scryptR, P: scryptP, DkLen: scryptDkLen, Salt: hex.EncodeToString(salt)}
+	aead, err := newKeyCipher(passphrase, params, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &KeyCrypto{
+		Cipher:       CipherName,
+		CipherText:   hex.EncodeToString(aead.Seal(nil, nonce, plainText, additionalData)),
+		CipherParams: CipherParams{Nonce: hex.EncodeToString(nonce)},
+		Kdf:          KdfName,
+		KdfParams:    params,
+	}, nil
+}
+
+func openKeyCrypto(keyFile *KeyFile, passphrase string) ([]byte, error) {
+	keyCrypto := keyFile.Crypto
+	if keyCrypto == nil {
+		return nil, fmt.Errorf("%w: no encrypted content", ErrInvalidKeyFile)
+	}
+	if keyFile.Version != KeyFileVersion || keyCrypto.Cipher != CipherName || keyCrypto.Kdf != KdfName {
+		return nil, fmt.Errorf("%w: unsupported version %d, cipher %q or kdf %q", ErrInvalidKeyFile, keyFile.Version, keyCrypto.Cipher, keyCrypto.Kdf)
+	}
+
+	params := keyCrypto.KdfParams
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: salt: %v", ErrInvalidKeyFile, err)
+	}
+	nonce, err := hex.DecodeString(keyCrypto.CipherParams.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce: %v", ErrInvalidKeyFile, err)
+	}
+	cipherText, err := hex.DecodeString(keyCrypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext: %v", ErrInvalidKeyFile, err)
+	}
+
+	aead, err := newKeyCipher(passphrase, params, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce must be %d bytes", ErrInvalidKeyFile, aead.NonceSize())
+	}
+
+	plainText, err := aead.Open(nil, nonce, cipherText, keyFile.additionalData())
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return plainText, nil
+}
+
+// additionalData is authenticated along with the ciphertext. It binds the kind and the address of the key file,
+// so sealed content only opens under the labels it was sealed with.
+func (keyFile *KeyFile) additionalData() []byte {
+	return []byte(keyFile.Kind + keyFile.Address)
+}
+
+func newKeyCipher(passphrase string, params ScryptParams, salt []byte) (cipher.AEAD, error) {
+	if params.N <= 1 || params.N > maxScryptN || params.N&(params.N-1) != 0 {
+		return nil, fmt.Errorf("%w: scrypt n must be a power of two up to %d", ErrInvalidKeyFile, maxScryptN)
+	}
+	if params.R != scryptR || params.P < 1 || params.DkLen != scryptDkLen || len(salt) == 0 {
+		return nil, fmt.Errorf("%w: unsupported scrypt parameters", ErrInvalidKeyFile)
+	}
+
+	scryptSlots <- struct{}{}
+	derivedKey, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, params.DkLen)
+	<-scryptSlots
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParsePrivateKeyHex parses a hex encoded P-256 private key. Keys of fewer than 32 bytes, as earlier versions
+// of the node exported them when the scalar has leading zeros, are accepted.
+func ParsePrivateKeyHex(privateKeyHex string) (*ecdsa.PrivateKey, error) {
+	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	return privateKeyFromBytes(privateKeyBytes)
+}
+
+// privateKeyFromBytes parses a big endian P-256 scalar and derives its public key.
+func privateKeyFromBytes(privateKeyBytes []byte) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(privateKeyBytes)
+	if len(privateKeyBytes) > 32 || d.Sign() <= 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	x, y := curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+	return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}, nil
+}
@@ -0,0 +1,131 @@
+package keystore
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	errors "errors"
+	testing "testing"
+)
+
+func newTestKeyFile(t *testing.T) (*ecdsa.PrivateKey, *KeyFile) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile, err := EncryptKey(privateKey, "passphrase", LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, keyFile
+}
+
+// copyKeyFile copies the key file deep enough to tamper with its crypto parameters.
+func copyKeyFile(keyFile *KeyFile) *KeyFile {
+	copied := *keyFile
+	keyCrypto := *keyFile.Crypto
+	copied.Crypto = &keyCrypto
+	return &copied
+}
+
+func TestEncryptKeyRoundTrip(t *testing.T) {
+	privateKey, keyFile := newTestKeyFile(t)
+
+	if keyFile.Address != primitives.PublicKeyToAddress(&privateKey.PublicKey) {
+		t.Fatalf("key file names %s, not the address of its key", keyFile.Address)
+	}
+
+	decrypted, err := DecryptKey(keyFile, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.D.Cmp(privateKey.D) != 0 || !decrypted.PublicKey.Equal(&privateKey.PublicKey) {
+		t.Fatal("the decrypted key differs from the encrypted one")
+	}
+}
+
+func TestDecryptKeyRejectsWrongPassphrasesAndTamperedFiles(t *testing.T) {
+	_, keyFile := newTestKeyFile(t)
+	_, otherKeyFile := newTestKeyFile(t)
+
+	tests := []struct {
+		name       string
+		passphrase string
+		tamper     func(keyFile *KeyFile)
+		decrypt    func(keyFile *KeyFile, passphrase string) error
+		expected   error
+	}{
+		{
+			name:       "wrong passphrase",
+			passphrase: "wrong passphrase",
+			expected:   ErrInvalidPassphrase,
+		},
+		{
+			name:       "address of another key",
+			passphrase: "passphrase",
+			tamper:     func(keyFile *KeyFile) { keyFile.Address = otherKeyFile.Address },
+			expected:   ErrInvalidPassphrase,
+		},
+		{
+			name:       "relabelled as a seed",
+			passphrase: "passphrase",
+			tamper:     func(keyFile *KeyFile) { keyFile.Kind = KindSeed },
+			decrypt: func(keyFile *KeyFile, passphrase string) error {
+				_, err := DecryptSeed(keyFile, passphrase)
+				return err
+			},
+			expected: ErrInvalidPassphrase,
+		},
+		{
+			name:       "relabelled as a derived key",
+			passphrase: "passphrase",
+			tamper:     func(keyFile *KeyFile) { keyFile.Kind = KindDerived },
+			expected:   ErrInvalidKeyFile,
+		},
+		{
+			name:       "scrypt n above the maximum",
+			passphrase: "passphrase",
+			tamper:     func(keyFile *KeyFile) { keyFile.Crypto.KdfParams.N = maxScryptN << 1 },
+			expected:   ErrInvalidKeyFile,
+		},
+		{
+			name:       "scrypt n not a power of two",
+			passphrase: "passphrase",
+			tamper:     func(keyFile *KeyFile) { keyFile.Crypto.KdfParams.N = LightScryptN + 1 },
+			expected:   ErrInvalidKeyFile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tampered := copyKeyFile(keyFile)
+			if test.tamper != nil {
+				test.tamper(tampered)
+			}
+
+			decrypt := test.decrypt
+			if decrypt == nil {
+				decrypt = func(keyFile *KeyFile, passphrase string) error {
+					_, err := DecryptKey(keyFile, passphrase)
+					return err
+				}
+			}
+
+			if err := decrypt(tampered, test.passphrase); !errors.Is(err, test.expected) {
+				t.Fatalf("got %v, want %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestEncryptKeyRejectsScryptNAboveTheMaximum(t *testing.T) {
+	privateKey, _ := newTestKeyFile(t)
+
+	if _, err := EncryptKey(privateKey, "passphrase", maxScryptN<<1, LightScryptP); !errors.Is(err, ErrInvalidKeyFile) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidKeyFile)
+	}
+}
@@ -0,0 +1,455 @@
+package keystore
+
+import (
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	fs "io/fs"
+	os "os"
+	filepath "path/filepath"
+	sort "sort"
+	sync "sync"
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+var (
+	ErrKeyNotFound = errors.New("key not found")
+	ErrKeyLocked   = errors.New("key is locked")
+	ErrKeyMismatch = errors.New("key does not own the sender address")
+)
+
+// KeyInfo identifies a stored key without exposing it. Path is set for keys derived from a seed. PublicKey is
+// only known once the key was decrypted or derived, it is nil in listings.
+type KeyInfo struct {
+	Id        string
+	Address   string
+	Path      string
+	PublicKey *ecdsa.PublicKey
+}
+
+type unlockedKey struct {
+	privateKey *ecdsa.PrivateKey
+	// expiresAt is zero for keys unlocked until they are locked again.
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+// KeyStore keeps private keys encrypted in one key file per key in a directory. A key is only usable after it
+// was unlocked with its passphrase, it is then held decrypted in memory until the unlock times out or the key
+// is locked. Callers refer to keys by id, private keys never leave the store except to sign blocks. Keys of HD
+// wallets are derived from an encrypted seed file and unlocked with the seed's passphrase.
+type KeyStore struct {
+	directory string
+	options   settings.KeystoreOptions
+
+	mutex    sync.Mutex
+	unlocked map[string]*unlockedKey
+}
+
+func NewKeyStore(options settings.KeystoreOptions) (*KeyStore, error) {
+	if options.ScryptN == 0 {
+		options.ScryptN = StandardScryptN
+	}
+	if options.ScryptP == 0 {
+		options.ScryptP = StandardScryptP
+	}
+
+	if err := os.MkdirAll(options.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create the keystore directory: %v", err)
+	}
+
+	return &KeyStore{
+		directory: options.Directory,
+		options:   options,
+		unlocked:  map[string]*unlockedKey{},
+	}, nil
+}
+
+// NewKey generates a key and stores it encrypted with the passphrase.
+func (store *KeyStore) NewKey(passphrase string) (KeyInfo, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return KeyInfo{}, fmt.Errorf("failed to generate key: %v", err)
+	}
+
+	return store.ImportKey(privateKey, passphrase)
+}
+
+// ImportKey stores an existing key encrypted with the passphrase under a new id.
+func (store *KeyStore) ImportKey(privateKey *ecdsa.PrivateKey, passphrase string) (KeyInfo, error) {
+	keyFile, err := EncryptKey(privateKey, passphrase, store.options.ScryptN, store.options.ScryptP)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	if err := store.writeKeyFile(keyFile); err != nil {
+		return KeyInfo{}, err
+	}
+	return KeyInfo{Id: keyFile.Id, Address: keyFile.Address, PublicKey: &privateKey.PublicKey}, nil
+}
+
+// ImportSeed stores the seed of an HD wallet encrypted with the passphrase. The seed itself cannot be unlocked,
+// only the keys added with AddDerivedKeys can.
+func (store *KeyStore) ImportSeed(seed []byte, passphrase string) (KeyInfo, error) {
+	if _, err := hdwallet.NewMasterKey(seed); err != nil {
+		return KeyInfo{}, err
+	}
+
+	keyFile, err := EncryptSeed(seed, passphrase, store.options.ScryptN, store.options.ScryptP)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	if err := store.writeKeyFile(keyFile); err != nil {
+		return KeyInfo{}, err
+	}
+	return KeyInfo{Id: keyFile.Id}, nil
+}
+
+// AddDerivedKeys derives the keys at the paths from the seed and stores one derived key file for each. The seed
+// is decrypted once, the derived key files only record the seed id and the path. When a key fails, the key files
+// written before it are removed again.
+func (store *KeyStore) AddDerivedKeys(seedId string, passphrase string, paths []hdwallet.DerivationPath) ([]KeyInfo, error) {
+	master, err := store.unlockSeed(seedId, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []KeyInfo{}
+	for _, path := range paths {
+		key, err := store.addDerivedKey(master, seedId, path)
+		if err != nil {
+			return nil, store.removeAddedKeys(keys, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (store *KeyStore) addDerivedKey(master *hdwallet.ExtendedKey, seedId string, path hdwallet.DerivationPath) (KeyInfo, error) {
+	derived, err := master.Derive(path)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	publicKey := &derived.PrivateKey().PublicKey
+	keyFile := &KeyFile{
+		Id:      uuid.NewString(),
+		Kind:    KindDerived,
+		Address: primitives.PublicKeyToAddress(publicKey),
+		SeedId:  seedId,
+		Path:    path.String(),
+		Version: KeyFileVersion,
+	}
+
+	if err := store.writeKeyFile(keyFile); err != nil {
+		return KeyInfo{}, err
+	}
+	return KeyInfo{Id: keyFile.Id, Address: keyFile.Address, Path: keyFile.Path, PublicKey: publicKey}, nil
+}
+
+// removeAddedKeys removes the key files an interrupted AddDerivedKeys already wrote and returns its error.
+func (store *KeyStore) removeAddedKeys(keys []KeyInfo, err error) error {
+	for _, key := range keys {
+		if removeErr := store.removeKeyFile(key.Id); removeErr != nil {
+			err = fmt.Errorf("%w, and key file %s could not be removed: %v", err, key.Id, removeErr)
+		}
+	}
+	return err
+}
+
+// DeleteSeed removes a seed file together with every key derived from it, e.g. once its wallet was recovered
+// under a new seed. The derived keys go first, so no key file is ever left pointing to a missing seed.
+func (store *KeyStore) DeleteSeed(seedId string) error {
+	seedFile, err := store.readKeyFile(seedId)
+	if err != nil {
+		return err
+	}
+	if seedFile.Kind != KindSeed {
+		return ErrKeyNotFound
+	}
+
+	keyFiles, err := store.keyFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, keyFile := range keyFiles {
+		if keyFile.Kind != KindDerived || keyFile.SeedId != seedId {
+			continue
+		}
+
+		if err := store.removeKeyFile(keyFile.Id); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(store.keyFilePath(seedId))
+}
+
+// DeleteDerivedKey removes a single derived key file, e.g. one whose address could not be recorded. Its seed
+// and the other keys derived from it stay.
+func (store *KeyStore) DeleteDerivedKey(id string) error {
+	keyFile, err := store.readKeyFile(id)
+	if err != nil {
+		return err
+	}
+	if keyFile.Kind != KindDerived {
+		return ErrKeyNotFound
+	}
+
+	return store.removeKeyFile(id)
+}
+
+// Keys lists the stored keys ordered by address.
+func (store *KeyStore) Keys() ([]KeyInfo, error) {
+	keyFiles, err := store.keyFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []KeyInfo{}
+	for _, keyFile := range keyFiles {
+		if keyFile.Kind == KindSeed {
+			continue
+		}
+		keys = append(keys, KeyInfo{Id: keyFile.Id, Address: keyFile.Address, Path: keyFile.Path})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Address != keys[j].Address {
+			return keys[i].Address < keys[j].Address
+		}
+		return keys[i].Id < keys[j].Id
+	})
+	return keys, nil
+}
+
+// Unlock decrypts the key with the passphrase and keeps it usable for timeout. A zero timeout uses the
+// configured unlock timeout, a negative one keeps the key unlocked until Lock is called.
+func (store *KeyStore) Unlock(id string, passphrase string, timeout time.Duration) (KeyInfo, error) {
+	keyFile, err := store.readKeyFile(id)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	privateKey, err := store.decryptKeyFile(keyFile, passphrase)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	if timeout == 0 {
+		timeout = store.options.UnlockTimeout
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.lockLocked(id)
+
+	key := &unlockedKey{privateKey: privateKey}
+	if timeout > 0 {
+		key.expiresAt = time.Now().Add(timeout)
+		key.timer = time.AfterFunc(timeout, func() {
+			store.expire(id, key)
+		})
+	}
+	store.unlocked[id] = key
+
+	return KeyInfo{Id: keyFile.Id, Address: keyFile.Address, Path: keyFile.Path, PublicKey: &privateKey.PublicKey}, nil
+}
+
+// Lock drops the decrypted key from memory.
+func (store *KeyStore) Lock(id string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.lockLocked(id)
+}
+
+// SignTransaction signs the transaction with an unlocked key, which must own the sender address.
+func (store *KeyStore) SignTransaction(id string, transaction *primitives.BlockTransaction) error {
+	privateKey, err := store.SigningKey(id)
+	if err != nil {
+		return err
+	}
+
+	if primitives.PublicKeyToAddress(&privateKey.PublicKey) != transaction.FromAddress {
+		return ErrKeyMismatch
+	}
+	return transaction.SignTransaction(privateKey)
+}
+
+// SigningKey returns an unlocked key. Only the miner needs the key itself, it signs the headers of the
+// blocks it mines for as long as it runs.
+func (store *KeyStore) SigningKey(id string) (*ecdsa.PrivateKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key, ok := store.unlocked[id]
+	if !ok || (!key.expiresAt.IsZero() && time.Now().After(key.expiresAt)) {
+		if _, err := store.readKeyFile(id); errors.Is(err, ErrKeyNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, ErrKeyLocked
+	}
+
+	return key.privateKey, nil
+}
+
+// decryptKeyFile decrypts a private key file, or derives a derived key from its seed.
+func (store *KeyStore) decryptKeyFile(keyFile *KeyFile, passphrase string) (*ecdsa.PrivateKey, error) {
+	switch keyFile.Kind {
+	case KindPrivateKey:
+		return DecryptKey(keyFile, passphrase)
+	case KindDerived:
+		path, err := hdwallet.ParseDerivationPath(keyFile.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+		}
+
+		master, err := store.unlockSeed(keyFile.SeedId, passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		derived, err := master.Derive(path)
+		if err != nil {
+			return nil, err
+		}
+
+		privateKey := derived.PrivateKey()
+		if primitives.PublicKeyToAddress(&privateKey.PublicKey) != keyFile.Address {
+			return nil, fmt.Errorf("%w: the key does not belong to address %s", ErrInvalidKeyFile, keyFile.Address)
+		}
+		return privateKey, nil
+	default:
+		// Seeds are not signing keys.
+		return nil, ErrKeyNotFound
+	}
+}
+
+func (store *KeyStore) unlockSeed(seedId string, passphrase string) (*hdwallet.ExtendedKey, error) {
+	keyFile, err := store.readKeyFile(seedId)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := DecryptSeed(keyFile, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return hdwallet.NewMasterKey(seed)
+}
+
+func (store *KeyStore) expire(id string, key *unlockedKey) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// The key may have been unlocked again in the meantime.
+	if store.unlocked[id] == key {
+		delete(store.unlocked, id)
+	}
+}
+
+func (store *KeyStore) lockLocked(id string) {
+	if key, ok := store.unlocked[id]; ok {
+		if key.timer != nil {
+			key.timer.Stop()
+		}
+		delete(store.unlocked, id)
+	}
+}
+
+// removeKeyFile locks the key and removes its file. A file that is already gone is not an error.
+func (store *KeyStore) removeKeyFile(id string) error {
+	store.Lock(id)
+	if err := os.Remove(store.keyFilePath(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// keyFiles reads every key file of the directory. Files that are not valid key files are skipped.
+func (store *KeyStore) keyFiles() ([]*KeyFile, error) {
+	entries, err := os.ReadDir(store.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFiles := []*KeyFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		keyFile, err := store.readKeyFile(entry.Name()[:len(entry.Name())-len(".json")])
+		if err != nil {
+			continue
+		}
+		keyFiles = append(keyFiles, keyFile)
+	}
+
+	return keyFiles, nil
+}
+
+func (store *KeyStore) readKeyFile(id string) (*KeyFile, error) {
+	// Ids come from requests, only well formed ones may turn into a path.
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrKeyNotFound
+	}
+
+	content, err := os.ReadFile(store.keyFilePath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyFile := &KeyFile{}
+	if err := json.Unmarshal(content, keyFile); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+	if keyFile.Id != id {
+		return nil, fmt.Errorf("%w: file %s holds key %s", ErrInvalidKeyFile, id, keyFile.Id)
+	}
+	return keyFile, nil
+}
+
+// writeKeyFile writes the key file next to its final path and renames it, so a crash never leaves half a key.
+func (store *KeyStore) writeKeyFile(keyFile *KeyFile) error {
+	content, err := json.MarshalIndent(keyFile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temporaryFile, err := os.CreateTemp(store.directory, ".key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+
+	if _, err := temporaryFile.Write(content); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryFile.Name(), store.keyFilePath(keyFile.Id))
+}
+
+func (store *KeyStore) keyFilePath(id string) string {
+	return filepath.Join(store.directory, id+".json")
+}
@@ -0,0 +1,177 @@
+package keystore
+
+import (
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	bytes "bytes"
+	errors "errors"
+	testing "testing"
+	time "time"
+)
+
+func newTestKeyStore(t *testing.T) *KeyStore {
+	t.Helper()
+
+	store, err := NewKeyStore(settings.KeystoreOptions{Directory: t.TempDir(), ScryptN: LightScryptN, ScryptP: LightScryptP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestDeleteSeedRemovesTheSeedAndItsDerivedKeys(t *testing.T) {
+	store := newTestKeyStore(t)
+	paths := []hdwallet.DerivationPath{hdwallet.AccountPath(0).Child(0), hdwallet.AccountPath(0).Child(1)}
+
+	removedSeed, err := store.ImportSeed(bytes.Repeat([]byte{1}, 64), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddDerivedKeys(removedSeed.Id, "passphrase", paths); err != nil {
+		t.Fatal(err)
+	}
+
+	keptSeed, err := store.ImportSeed(bytes.Repeat([]byte{2}, 64), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keptKeys, err := store.AddDerivedKeys(keptSeed.Id, "passphrase", paths[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteSeed(removedSeed.Id); err != nil {
+		t.Fatalf("deleting the seed failed: %v", err)
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Id != keptKeys[0].Id {
+		t.Fatalf("kept keys %+v, want only the key of the other seed", keys)
+	}
+
+	if _, err := store.AddDerivedKeys(removedSeed.Id, "passphrase", paths[:1]); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("deriving from the deleted seed returned %v, want %v", err, ErrKeyNotFound)
+	}
+	if err := store.DeleteSeed(keptKeys[0].Id); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("deleting a derived key as a seed returned %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+func TestUnlockedKeysLockAgainAfterTheTimeout(t *testing.T) {
+	store := newTestKeyStore(t)
+
+	key, err := store.NewKey("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Unlock(key.Id, "passphrase", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SigningKey(key.Id); err != nil {
+		t.Fatalf("the key is not usable right after the unlock: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := store.SigningKey(key.Id); !errors.Is(err, ErrKeyLocked) {
+		t.Fatalf("got %v after the timeout, want %v", err, ErrKeyLocked)
+	}
+}
+
+func TestSignTransactionNeedsTheUnlockedKeyOfTheSender(t *testing.T) {
+	store := newTestKeyStore(t)
+
+	key, err := store.NewKey("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.NewKey("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transaction := primitives.NewBlockTransaction(key.Address, other.Address, primitives.AmountUnitsPerCoin, 1000, 0)
+	if err := store.SignTransaction(key.Id, transaction); !errors.Is(err, ErrKeyLocked) {
+		t.Fatalf("signing with a locked key returned %v, want %v", err, ErrKeyLocked)
+	}
+
+	if _, err := store.Unlock(key.Id, "passphrase", -1); err != nil {
+		t.Fatal(err)
+	}
+
+	foreign := primitives.NewBlockTransaction(other.Address, key.Address, primitives.AmountUnitsPerCoin, 1000, 0)
+	if err := store.SignTransaction(key.Id, foreign); !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("signing for another sender returned %v, want %v", err, ErrKeyMismatch)
+	}
+
+	if err := store.SignTransaction(key.Id, transaction); err != nil {
+		t.Fatal(err)
+	}
+	if err := transaction.Verify(); err != nil {
+		t.Fatalf("the signed transaction does not verify: %v", err)
+	}
+
+	store.Lock(key.Id)
+	if err := store.SignTransaction(key.Id, transaction); !errors.Is(err, ErrKeyLocked) {
+		t.Fatalf("signing after Lock returned %v, want %v", err, ErrKeyLocked)
+	}
+}
+
+func TestAddDerivedKeysRemovesItsKeysWhenOneFails(t *testing.T) {
+	store := newTestKeyStore(t)
+
+	seed, err := store.ImportSeed(bytes.Repeat([]byte{1}, 64), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths deeper than 255 levels cannot be derived.
+	tooDeep := make(hdwallet.DerivationPath, 256)
+	paths := []hdwallet.DerivationPath{hdwallet.AccountPath(0).Child(0), hdwallet.AccountPath(0).Child(1), tooDeep}
+	if _, err := store.AddDerivedKeys(seed.Id, "passphrase", paths); err == nil {
+		t.Fatal("a path deeper than 255 levels was derived")
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("the keys of the failed derivation were kept: %+v", keys)
+	}
+}
+
+func TestDeleteDerivedKeyKeepsTheSeedAndItsOtherKeys(t *testing.T) {
+	store := newTestKeyStore(t)
+
+	seed, err := store.ImportSeed(bytes.Repeat([]byte{1}, 64), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := store.AddDerivedKeys(seed.Id, "passphrase", []hdwallet.DerivationPath{hdwallet.AccountPath(0).Child(0), hdwallet.AccountPath(0).Child(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteDerivedKey(seed.Id); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("deleting a seed as a derived key returned %v, want %v", err, ErrKeyNotFound)
+	}
+	if err := store.DeleteDerivedKey(derived[0].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Id != derived[1].Id {
+		t.Fatalf("kept keys %+v, want only %s", keys, derived[1].Id)
+	}
+	if _, err := store.AddDerivedKeys(seed.Id, "passphrase", []hdwallet.DerivationPath{hdwallet.AccountPath(0).Child(2)}); err != nil {
+		t.Fatalf("the seed is gone with its key: %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package mempool
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	utilities "bitshare-chain/infrastructure/utilities"
+	heap "container/heap"
+	errors "errors"
+	fmt "fmt"
+	bits "math/bits"
+	sort "sort"
+	sync "sync"
+	time "time"
+)
+
+var (
+	ErrAlreadyKnown       = errors.New("transaction is already in the mempool")
+	ErrMempoolFull        = errors.New("mempool is full")
+	ErrSenderLimitReached = errors.New("sender has too many pending transactions")
+	ErrNonceTooLow        = errors.New("transaction nonce is already used")
+	ErrFeeTooLow          = errors.New("transaction fee is below the minimum relay fee")
+)
+
+// ChainState is the view of the chain the mempool validates against.
+type ChainState interface {
+	GetAccountState(address string) utilities.AccountState
+	GetMatureBalance(address string) primitives.Amount
+}
+
+// Entry is a pending transaction together with what the mempool needs to order and expire it.
+type Entry struct {
+	Transaction primitives.BlockTransaction
+	Id          string
+	Fee         primitives.Amount
+	Size        int
+	AddedAt     time.Time
+}
+
+func newEntry(transaction primitives.BlockTransaction, addedAt time.Time) *Entry {
+	return &Entry{
+		Transaction: transaction,
+		Id:          transaction.TransactionId(),
+		Fee:         transaction.Fee,
+		Size:        transaction.Size(),
+		AddedAt:     addedAt,
+	}
+}
+
+// compareFeeRate compares the fee per byte of two entries without dividing.
+func compareFeeRate(first *Entry, second *Entry) int {
+	firstHigh, firstLow := bits.Mul64(uint64(first.Fee), uint64(second.Size))
+	secondHigh, secondLow := bits.Mul64(uint64(second.Fee), uint64(first.Size))
+
+	if firstHigh != secondHigh {
+		return compareUint64(firstHigh, secondHigh)
+	}
+	return compareUint64(firstLow, secondLow)
+}
+
+func compareUint64(first uint64, second uint64) int {
+	switch {
+	case first < second:
+		return -1
+	case first > second:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Mempool holds the transactions waiting to be mined. Every sender has a queue ordered by nonce that
+// continues right after the sender's confirmed nonce and is covered by the confirmed balance, so
+// every prefix of a queue can be mined as is. All methods are safe for concurrent use.
+type Mempool struct {
+	mutex    sync.RWMutex
+	options  settings.MempoolOptions
+	chain    ChainState
+	entries  map[string]*Entry
+	bySender map[string][]*Entry
+	now      func() time.Time
+
+	subscribers []func()
+}
+
+func NewMempool(options settings.MempoolOptions, chain ChainState) *Mempool {
+	return &Mempool{
+		options:  options,
+		chain:    chain,
+		entries:  map[string]*Entry{},
+		bySender: map[string][]*Entry{},
+		now:      time.Now,
+	}
+}
+
+// Subscribe registers a handler that is called whenever a transaction was added, e.g. to refresh a block template.
+func (pool *Mempool) Subscribe(handler func()) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.subscribers = append(pool.subscribers, handler)
+}
+
+// Add validates a transaction against the chain and the sender's pending transactions and queues it.
+// Subscribers are called after the mempool was unlocked.
+func (pool *Mempool) Add(transaction primitives.BlockTransaction) error {
+	pool.mutex.Lock()
+	pool.removeExpired()
+	err := pool.add(newEntry(transaction, pool.now()))
+	subscribers := pool.subscribers
+	pool.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for _, handler := range subscribers {
+		handler()
+	}
+	return nil
+}
+
+func (pool *Mempool) Get(transactionId string) (primitives.BlockTransaction, bool) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	entry, ok := pool.entries[transactionId]
+	if !ok {
+		return primitives.BlockTransaction{}, false
+	}
+	return entry.Transaction, true
+}
+
+func (pool *Mempool) Count() int {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return len(pool.entries)
+}
+
+// Pending returns every pending transaction, grouped by sender and ordered by nonce.
+func (pool *Mempool) Pending() []primitives.BlockTransaction {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	senders := make([]string, 0, len(pool.bySender))
+	for sender := range pool.bySender {
+		senders = append(senders, sender)
+	}
+	sort.Strings(senders)
+
+	transactions := make([]primitives.BlockTransaction, 0, len(pool.entries))
+	for _, sender := range senders {
+		for _, entry := range pool.bySender[sender] {
+			transactions = append(transactions, entry.Transaction)
+		}
+	}
+	return transactions
+}
+
+// NextNonce returns the nonce the next transaction of the address has to carry.
+func (pool *Mempool) NextNonce(address string) uint64 {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return pool.chain.GetAccountState(address).Nonce + uint64(len(pool.bySender[address]))
+}
+
+// SpendableBalance is the mature confirmed balance minus everything the address spends in pending transactions.
+// Pending credits are not counted, they may never be mined.
+func (pool *Mempool) SpendableBalance(address string) (primitives.Amount, error) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return pool.spendableBalance(address)
+}
+
+// MinimumFee is the relay fee a transaction of the given canonical size has to pay to be accepted.
+func (pool *Mempool) MinimumFee(size int) primitives.Amount {
+	return pool.minimumFee(size)
+}
+
+// SelectTransactions builds the transaction list of a block template. The sender queue whose next
+// transaction pays the highest fee rate goes first, ties go to the older transaction, and a sender's
+// transactions are always taken in nonce order. Expired transactions are dropped first, so a pool
+// that stopped receiving transactions never hands out stale ones.
+func (pool *Mempool) SelectTransactions(maxTransactions int) []primitives.BlockTransaction {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.removeExpired()
+
+	queues := &senderQueues{}
+	for _, queue := range pool.bySender {
+		heap.Push(queues, queue)
+	}
+
+	selected := []primitives.BlockTransaction{}
+	for queues.Len() > 0 && len(selected) < maxTransactions {
+		queue := heap.Pop(queues).([]*Entry)
+		selected = append(selected, queue[0].Transaction)
+
+		if len(queue) > 1 {
+			heap.Push(queues, queue[1:])
+		}
+	}
+
+	return selected
+}
+
+// Revalidate rebuilds the queues on top of the current chain state, e.g. after a new block or a
+// reorganization. Transactions that were mined or no longer fit are dropped. Orphaned transactions
+// of disconnected blocks are offered again.
+func (pool *Mempool) Revalidate(orphaned ...primitives.BlockTransaction) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := pool.now()
+	candidates := make([]*Entry, 0, len(pool.entries)+len(orphaned))
+	for index := range orphaned {
+		candidates = append(candidates, newEntry(orphaned[index], now))
+	}
+	for _, entry := range pool.entries {
+		candidates = append(candidates, entry)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Transaction.FromAddress != candidates[j].Transaction.FromAddress {
+			return candidates[i].Transaction.FromAddress < candidates[j].Transaction.FromAddress
+		}
+		return candidates[i].Transaction.Nonce < candidates[j].Transaction.Nonce
+	})
+
+	pool.entries = map[string]*Entry{}
+	pool.bySender = map[string][]*Entry{}
+	for _, entry := range candidates {
+		// Invalid transactions are dropped, there is nobody to report the error to.
+		_ = pool.add(entry)
+	}
+
+	pool.removeExpired()
+}
+
+func (pool *Mempool) add(entry *Entry) error {
+	transaction := &entry.Transaction
+
+	if _, ok := pool.entries[entry.Id]; ok {
+		return ErrAlreadyKnown
+	}
+
+	if transaction.Kind != primitives.TransactionKindTransfer {
+		return errors.New("only transfers can be added to the mempool")
+	}
+
+	if transaction.FromAddress == "" || transaction.ToAddress == "" {
+		return errors.New("transaction must include from and to address")
+	}
+
+	if !transaction.IsValid() {
+		return errors.New("transaction signature is not valid")
+	}
+
+	if !transaction.Amount.IsPositive() {
+		return fmt.Errorf("%w: transaction amount must be positive", primitives.ErrInvalidAmount)
+	}
+
+	if transaction.Fee.IsNegative() {
+		return fmt.Errorf("%w: transaction fee cannot be negative", primitives.ErrInvalidAmount)
+	}
+
+	if minimumFee := pool.minimumFee(entry.Size); transaction.Fee < minimumFee {
+		return fmt.Errorf("%w: %s for %d bytes, at least %s", ErrFeeTooLow, transaction.Fee, entry.Size, minimumFee)
+	}
+
+	sender := transaction.FromAddress
+	confirmedNonce := pool.chain.GetAccountState(sender).Nonce
+	if transaction.Nonce < confirmedNonce {
+		return fmt.Errorf("%w: confirmed nonce is %d", ErrNonceTooLow, confirmedNonce)
+	}
+
+	if expectedNonce := confirmedNonce + uint64(len(pool.bySender[sender])); transaction.Nonce != expectedNonce {
+		return fmt.Errorf("%w: expected %d, got %d", utilities.ErrInvalidNonce, expectedNonce, transaction.Nonce)
+	}
+
+	spendable, err := pool.spendableBalance(sender)
+	if err != nil {
+		return err
+	}
+
+	cost, err := transaction.TotalCost()
+	if err != nil {
+		return err
+	}
+
+	if spendable < cost {
+		return fmt.Errorf("%w: spendable %s, amount and fee %s", utilities.ErrInsufficientBalance, spendable, cost)
+	}
+
+	if limit := pool.options.MaxTransactionsPerSender; limit > 0 && len(pool.bySender[sender]) >= limit {
+		return ErrSenderLimitReached
+	}
+
+	if limit := pool.options.MaxTransactions; limit > 0 && len(pool.entries) >= limit {
+		if !pool.evictFor(entry) {
+			return ErrMempoolFull
+		}
+	}
+
+	pool.entries[entry.Id] = entry
+	pool.bySender[sender] = append(pool.bySender[sender], entry)
+	return nil
+}
+
+// evictFor makes room for an entry by dropping the transaction with the lowest fee rate that is last in its
+// sender queue, so no queue gets a gap. Nothing is evicted unless the new entry pays a higher fee rate.
+func (pool *Mempool) evictFor(entry *Entry) bool {
+	var cheapest *Entry
+	for sender, queue := range pool.bySender {
+		if sender == entry.Transaction.FromAddress {
+			continue
+		}
+
+		last := queue[len(queue)-1]
+		if cheapest == nil {
+			cheapest = last
+			continue
+		}
+
+		if comparison := compareFeeRate(last, cheapest); comparison < 0 || (comparison == 0 && last.AddedAt.After(cheapest.AddedAt)) {
+			cheapest = last
+		}
+	}
+
+	if cheapest == nil || compareFeeRate(cheapest, entry) >= 0 {
+		return false
+	}
+
+	pool.removeTail(cheapest)
+	return true
+}
+
+// removeExpired drops transactions that waited longer than the expiry together with every later
+// transaction of the same sender, which could not be mined without them.
+func (pool *Mempool) removeExpired() {
+	if pool.options.TransactionExpiry <= 0 {
+		return
+	}
+
+	cutoff := pool.now().Add(-pool.options.TransactionExpiry)
+	for _, queue := range pool.bySender {
+		for _, entry := range queue {
+			if entry.AddedAt.Before(cutoff) {
+				pool.removeTail(entry)
+				break
+			}
+		}
+	}
+}
+
+// removeTail removes an entry and everything queued after it by the same sender.
+func (pool *Mempool) removeTail(entry *Entry) {
+	sender := entry.Transaction.FromAddress
+	queue := pool.bySender[sender]
+
+	for index, queued := range queue {
+		if queued != entry {
+			continue
+		}
+
+		for _, removed := range queue[index:] {
+			delete(pool.entries, removed.Id)
+		}
+
+		if index == 0 {
+			delete(pool.bySender, sender)
+		} else {
+			pool.bySender[sender] = queue[:index]
+		}
+		return
+	}
+}
+
+func (pool *Mempool) spendableBalance(address string) (primitives.Amount, error) {
+	balance := pool.chain.GetMatureBalance(address)
+
+	for _, entry := range pool.bySender[address] {
+		cost, err := entry.Transaction.TotalCost()
+		if err != nil {
+			return 0, err
+		}
+
+		if balance, err = balance.Sub(cost); err != nil {
+			return 0, err
+		}
+	}
+
+	return balance, nil
+}
+
+// minimumFee is the relay fee a transaction of the given size has to pay, rounded up.
+func (pool *Mempool) minimumFee(size int) primitives.Amount {
+	if pool.options.MinRelayFeePerKb <= 0 {
+		return 0
+	}
+
+	return primitives.Amount((pool.options.MinRelayFeePerKb*int64(size) + 999) / 1000)
+}
+
+// senderQueues is a max heap of sender queues ordered by the fee rate of their first transaction.
+type senderQueues [][]*Entry
+
+func (queues senderQueues) Len() int { return len(queues) }
+
+func (queues senderQueues) Less(i, j int) bool {
+	first, second := queues[i][0], queues[j][0]
+	if comparison := compareFeeRate(first, second); comparison != 0 {
+		return comparison > 0
+	}
+	return first.AddedAt.Before(second.AddedAt)
+}
+
+func (queues senderQueues) Swap(i, j int) { queues[i], queues[j] = queues[j], queues[i] }
+
+func (queues *senderQueues) Push(queue any) { *queues = append(*queues, queue.([]*Entry)) }
+
+func (queues *senderQueues) Pop() any {
+	old := *queues
+	queue := old[len(old)-1]
+	*queues = old[:len(old)-1]
+	return queue
+}
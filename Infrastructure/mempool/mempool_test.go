@@ -0,0 +1,298 @@
+package mempool
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	utilities "bitshare-chain/infrastructure/utilities"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	errors "errors"
+	reflect "reflect"
+	testing "testing"
+	time "time"
+)
+
+const coin = primitives.AmountUnitsPerCoin
+
+type chainState map[string]utilities.AccountState
+
+func (chain chainState) GetAccountState(address string) utilities.AccountState {
+	return chain[address]
+}
+
+func (chain chainState) GetMatureBalance(address string) primitives.Amount {
+	return chain[address].Balance
+}
+
+type testAccount struct {
+	key     *ecdsa.PrivateKey
+	address string
+}
+
+func newTestAccount(t *testing.T) testAccount {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testAccount{key: key, address: primitives.PublicKeyToAddress(&key.PublicKey)}
+}
+
+func transfer(t *testing.T, from testAccount, to testAccount, amount primitives.Amount, fee primitives.Amount, nonce uint64) primitives.BlockTransaction {
+	t.Helper()
+
+	transaction := primitives.NewBlockTransaction(from.address, to.address, amount, fee, nonce)
+	if err := transaction.SignTransaction(from.key); err != nil {
+		t.Fatal(err)
+	}
+	return *transaction
+}
+
+// newTestMempool is a pool with a relay fee of about a fifth of the 1000 unit fees the tests pay and a clock
+// that only moves when a test moves it.
+func newTestMempool(options settings.MempoolOptions, chain chainState) (*Mempool, *time.Time) {
+	if options.MinRelayFeePerKb == 0 {
+		options.MinRelayFeePerKb = 1000
+	}
+
+	pool := NewMempool(options, chain)
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	return pool, &now
+}
+
+func addAll(t *testing.T, pool *Mempool, transactions ...primitives.BlockTransaction) {
+	t.Helper()
+
+	for _, transaction := range transactions {
+		if err := pool.Add(transaction); err != nil {
+			t.Fatalf("nonce %d, fee %s: %v", transaction.Nonce, transaction.Fee, err)
+		}
+	}
+}
+
+func transactionIds(transactions []primitives.BlockTransaction) []string {
+	ids := []string{}
+	for index := range transactions {
+		ids = append(ids, transactions[index].TransactionId())
+	}
+	return ids
+}
+
+func TestAddRejectsDuplicates(t *testing.T) {
+	sender, recipient := newTestAccount(t), newTestAccount(t)
+	pool, _ := newTestMempool(settings.MempoolOptions{}, chainState{sender.address: {Address: sender.address, Balance: 10 * coin}})
+
+	transaction := transfer(t, sender, recipient, coin, 1000, 0)
+	addAll(t, pool, transaction)
+
+	if err := pool.Add(transaction); !errors.Is(err, ErrAlreadyKnown) {
+		t.Fatalf("got %v, want %v", err, ErrAlreadyKnown)
+	}
+	if pool.Count() != 1 {
+		t.Fatalf("got %d pending transactions, want 1", pool.Count())
+	}
+}
+
+func TestAddRequiresContiguousNonces(t *testing.T) {
+	sender, recipient := newTestAccount(t), newTestAccount(t)
+	pool, _ := newTestMempool(settings.MempoolOptions{}, chainState{sender.address: {Address: sender.address, Balance: 10 * coin, Nonce: 3}})
+
+	tests := []struct {
+		nonce    uint64
+		expected error
+	}{
+		{2, ErrNonceTooLow},
+		{4, utilities.ErrInvalidNonce},
+		{3, nil},
+		{3, utilities.ErrInvalidNonce},
+		{5, utilities.ErrInvalidNonce},
+		{4, nil},
+	}
+
+	for index, test := range tests {
+		// The amount makes every transaction distinct, so a reused nonce is not reported as a duplicate.
+		err := pool.Add(transfer(t, sender, recipient, coin+primitives.Amount(index), 1000, test.nonce))
+		if (test.expected == nil && err != nil) || (test.expected != nil && !errors.Is(err, test.expected)) {
+			t.Fatalf("nonce %d: got %v, want %v", test.nonce, err, test.expected)
+		}
+	}
+
+	if nonce := pool.NextNonce(sender.address); nonce != 5 {
+		t.Fatalf("got next nonce %d, want 5", nonce)
+	}
+}
+
+func TestAddChecksTheBalanceLeftByPendingSpends(t *testing.T) {
+	sender, recipient := newTestAccount(t), newTestAccount(t)
+	pool, _ := newTestMempool(settings.MempoolOptions{}, chainState{sender.address: {Address: sender.address, Balance: 10 * coin}})
+
+	addAll(t, pool, transfer(t, sender, recipient, 6*coin, 1000, 0))
+	if spendable, err := pool.SpendableBalance(sender.address); err != nil || spendable != 4*coin-1000 {
+		t.Fatalf("got %s, %v, want %s", spendable, err, 4*coin-1000)
+	}
+
+	if err := pool.Add(transfer(t, sender, recipient, 4*coin, 1000, 1)); !errors.Is(err, utilities.ErrInsufficientBalance) {
+		t.Fatalf("got %v, want %v", err, utilities.ErrInsufficientBalance)
+	}
+
+	addAll(t, pool, transfer(t, sender, recipient, 4*coin-2000, 1000, 1))
+	if spendable, err := pool.SpendableBalance(sender.address); err != nil || spendable != 0 {
+		t.Fatalf("got %s, %v, want 0", spendable, err)
+	}
+
+	// Pending credits may never be mined, the recipient cannot spend them yet.
+	if err := pool.Add(transfer(t, recipient, sender, coin, 1000, 0)); !errors.Is(err, utilities.ErrInsufficientBalance) {
+		t.Fatalf("spending a pending credit returned %v, want %v", err, utilities.ErrInsufficientBalance)
+	}
+}
+
+func TestAddEnforcesTheMinimumRelayFee(t *testing.T) {
+	sender, recipient := newTestAccount(t), newTestAccount(t)
+	pool, _ := newTestMempool(settings.MempoolOptions{MinRelayFeePerKb: 10_000}, chainState{sender.address: {Address: sender.address, Balance: 10 * coin}})
+
+	unpaid := transfer(t, sender, recipient, coin, 0, 0)
+	size := unpaid.Size()
+	minimumFee := pool.MinimumFee(size)
+	if expected := primitives.Amount((10_000*size + 999) / 1000); minimumFee != expected {
+		t.Fatalf("got a minimum fee of %s for %d bytes, want %s", minimumFee, size, expected)
+	}
+
+	if err := pool.Add(transfer(t, sender, recipient, coin, minimumFee-1, 0)); !errors.Is(err, ErrFeeTooLow) {
+		t.Fatalf("got %v, want %v", err, ErrFeeTooLow)
+	}
+	addAll(t, pool, transfer(t, sender, recipient, coin, minimumFee, 0))
+}
+
+func TestAddEnforcesTheSenderLimit(t *testing.T) {
+	sender, other, recipient := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	pool, _ := newTestMempool(settings.MempoolOptions{MaxTransactionsPerSender: 2}, chainState{
+		sender.address: {Address: sender.address, Balance: 10 * coin},
+		other.address:  {Address: other.address, Balance: 10 * coin},
+	})
+
+	addAll(t, pool, transfer(t, sender, recipient, coin, 1000, 0), transfer(t, sender, recipient, coin, 1000, 1))
+	if err := pool.Add(transfer(t, sender, recipient, coin, 1000, 2)); !errors.Is(err, ErrSenderLimitReached) {
+		t.Fatalf("got %v, want %v", err, ErrSenderLimitReached)
+	}
+	addAll(t, pool, transfer(t, other, recipient, coin, 1000, 0))
+}
+
+func TestAddEvictsTheCheapestQueueTail(t *testing.T) {
+	first, second, third, fourth, recipient := newTestAccount(t), newTestAccount(t), newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	chain := chainState{}
+	for _, account := range []testAccount{first, second, third, fourth} {
+		chain[account.address] = utilities.AccountState{Address: account.address, Balance: 10 * coin}
+	}
+	pool, _ := newTestMempool(settings.MempoolOptions{MaxTransactions: 3}, chain)
+
+	cheapHead := transfer(t, first, recipient, coin, 1000, 0)
+	expensiveTail := transfer(t, first, recipient, coin, 5000, 1)
+	cheapestTail := transfer(t, second, recipient, coin, 2000, 0)
+	addAll(t, pool, cheapHead, expensiveTail, cheapestTail)
+
+	// The head of the first queue pays less, but removing it would leave a gap in front of its tail.
+	replacement := transfer(t, third, recipient, coin, 3000, 0)
+	addAll(t, pool, replacement)
+	if _, ok := pool.Get(cheapestTail.TransactionId()); ok || pool.Count() != 3 {
+		t.Fatalf("the cheapest tail was not evicted, %d transactions are pending", pool.Count())
+	}
+	if _, ok := pool.Get(cheapHead.TransactionId()); !ok {
+		t.Fatal("the head of a queue was evicted")
+	}
+
+	// A sender never evicts its own transactions, and nothing is evicted for a lower fee rate.
+	addAll(t, pool, transfer(t, first, recipient, coin, 9000, 2))
+	if _, ok := pool.Get(replacement.TransactionId()); ok {
+		t.Fatal("the cheapest other tail was not evicted")
+	}
+	if err := pool.Add(transfer(t, fourth, recipient, coin, 1000, 0)); !errors.Is(err, ErrMempoolFull) {
+		t.Fatalf("got %v, want %v", err, ErrMempoolFull)
+	}
+	if pool.Count() != 3 {
+		t.Fatalf("got %d pending transactions, want 3", pool.Count())
+	}
+}
+
+func TestSelectTransactionsOrdersByFeeWithinNonceOrder(t *testing.T) {
+	first, second, third, recipient := newTestAccount(t), newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	chain := chainState{}
+	for _, account := range []testAccount{first, second, third} {
+		chain[account.address] = utilities.AccountState{Address: account.address, Balance: 10 * coin}
+	}
+	pool, _ := newTestMempool(settings.MempoolOptions{}, chain)
+
+	firstCheap := transfer(t, first, recipient, coin, 1000, 0)
+	firstExpensive := transfer(t, first, recipient, coin, 9000, 1)
+	secondExpensive := transfer(t, second, recipient, coin, 5000, 0)
+	secondCheap := transfer(t, second, recipient, coin, 2000, 1)
+	third0 := transfer(t, third, recipient, coin, 3000, 0)
+	addAll(t, pool, firstCheap, firstExpensive, secondExpensive, secondCheap, third0)
+
+	// The 9000 unit fee only becomes available once the cheap transaction in front of it is taken.
+	expected := transactionIds([]primitives.BlockTransaction{secondExpensive, third0, secondCheap, firstCheap, firstExpensive})
+	if selected := transactionIds(pool.SelectTransactions(10)); !reflect.DeepEqual(selected, expected) {
+		t.Fatalf("got %v, want %v", selected, expected)
+	}
+	if selected := transactionIds(pool.SelectTransactions(2)); !reflect.DeepEqual(selected, expected[:2]) {
+		t.Fatalf("got %v, want %v", selected, expected[:2])
+	}
+	if pool.Count() != 5 {
+		t.Fatalf("selecting removed transactions, %d are pending", pool.Count())
+	}
+}
+
+func TestRevalidateReaddsOrphansAfterAReorganization(t *testing.T) {
+	sender, recipient := newTestAccount(t), newTestAccount(t)
+	chain := chainState{sender.address: {Address: sender.address, Balance: 10*coin - coin - 1000, Nonce: 1}}
+	pool, _ := newTestMempool(settings.MempoolOptions{}, chain)
+
+	mined := transfer(t, sender, recipient, coin, 1000, 0)
+	pending := transfer(t, sender, recipient, coin, 1000, 1)
+	addAll(t, pool, pending)
+
+	// The block with the first transaction is disconnected, the sender's state goes back to before it.
+	chain[sender.address] = utilities.AccountState{Address: sender.address, Balance: 10 * coin}
+	pool.Revalidate(mined)
+
+	expected := transactionIds([]primitives.BlockTransaction{mined, pending})
+	if got := transactionIds(pool.Pending()); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %v, want %v", got, expected)
+	}
+
+	// The new branch mines both transactions.
+	chain[sender.address] = utilities.AccountState{Address: sender.address, Balance: 8*coin - 2000, Nonce: 2}
+	pool.Revalidate()
+	if pool.Count() != 0 {
+		t.Fatalf("%d mined transactions are still pending", pool.Count())
+	}
+}
+
+func TestSelectTransactionsDropsExpiredTransactions(t *testing.T) {
+	sender, recipient := newTestAccount(t), newTestAccount(t)
+	pool, now := newTestMempool(settings.MempoolOptions{
+		MaxTransactions:          10,
+		MaxTransactionsPerSender: 10,
+		TransactionExpiry:        time.Hour,
+		MinRelayFeePerKb:         1000,
+	}, chainState{sender.address: {Address: sender.address, Balance: 100 * coin}})
+
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		addAll(t, pool, transfer(t, sender, recipient, coin, 1000, nonce))
+	}
+
+	if selected := pool.SelectTransactions(10); len(selected) != 2 {
+		t.Fatalf("selected %d transactions before the expiry, want 2", len(selected))
+	}
+
+	// Nothing is added after the expiry, selecting alone has to drop the transactions.
+	*now = now.Add(time.Hour + time.Second)
+	if selected := pool.SelectTransactions(10); len(selected) != 0 {
+		t.Fatalf("selected %d expired transactions", len(selected))
+	}
+	if len(pool.entries) != 0 {
+		t.Fatalf("%d expired transactions are still pending", len(pool.entries))
+	}
+}
@@ -0,0 +1,210 @@
+package mining
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	utilities "bitshare-chain/infrastructure/utilities"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	errors "errors"
+	fmt "fmt"
+	sync "sync"
+	time "time"
+)
+
+var ErrAlreadyMining = errors.New("the miner is already mining")
+
+// Chain is the part of the blockchain the miner builds on.
+type Chain interface {
+	NewBlockTemplate(transactions []primitives.BlockTransaction, miningRewardAddress string) (*utilities.Block, error)
+	SubmitBlock(block *utilities.Block) error
+	SubscribeBlocks(handler func(block utilities.Block))
+	SubscribeReorgs(handler func(event utilities.ReorgEvent))
+}
+
+// TransactionSource provides the transactions of a block template, usually the mempool.
+type TransactionSource interface {
+	SelectTransactions(maxTransactions int) []primitives.BlockTransaction
+	Subscribe(handler func())
+}
+
+// MiningStatus describes the current mining session, or the last one when the miner is idle.
+type MiningStatus struct {
+	Mining bool
+	// Height is the height of the block the current template is mined for.
+	Height        int64
+	Transactions  int
+	Hashes        uint64
+	HashRate      float64
+	Restarts      uint64
+	BlocksMined   uint64
+	LastBlockHash string
+	StartedAt     time.Time
+}
+
+// Miner mines blocks on top of the chain tip. The template of the block being mined is abandoned and rebuilt
+// whenever the tip changes, because another block arrived or a reorganization happened, and whenever
+// transactions are added to the source. Restarting costs nothing, every hash has the same chance to succeed.
+type Miner struct {
+	chain   Chain
+	source  TransactionSource
+	options settings.MiningOptions
+
+	mutex         sync.Mutex
+	status        MiningStatus
+	cancelAttempt context.CancelFunc
+}
+
+func NewMiner(chain Chain, source TransactionSource, options settings.MiningOptions) *Miner {
+	miner := &Miner{
+		chain:   chain,
+		source:  source,
+		options: options,
+	}
+
+	chain.SubscribeBlocks(func(block utilities.Block) {
+		miner.restart()
+	})
+	chain.SubscribeReorgs(func(event utilities.ReorgEvent) {
+		miner.restart()
+	})
+	source.Subscribe(miner.restart)
+
+	return miner
+}
+
+// Status returns a snapshot of the mining session.
+func (miner *Miner) Status() MiningStatus {
+	miner.mutex.Lock()
+	defer miner.mutex.Unlock()
+
+	status := miner.status
+	if status.Mining {
+		if elapsed := time.Since(status.StartedAt).Seconds(); elapsed > 0 {
+			status.HashRate = float64(status.Hashes) / elapsed
+		}
+	}
+	return status
+}
+
+// MineBlock mines until one block paying the given address was connected to the chain, or until ctx is cancelled.
+func (miner *Miner) MineBlock(ctx context.Context, miningRewardAddress string, signingKey *ecdsa.PrivateKey) (*utilities.Block, error) {
+	if err := miner.start(); err != nil {
+		return nil, err
+	}
+	defer miner.stop()
+
+	return miner.mineBlock(ctx, miningRewardAddress, signingKey)
+}
+
+// Run mines blocks one after the other until ctx is cancelled and calls mined with every block connected to the
+// chain. It only returns early when a template cannot be built or a mined block is rejected for another reason
+// than a moved tip.
+func (miner *Miner) Run(ctx context.Context, miningRewardAddress string, signingKey *ecdsa.PrivateKey, mined func(block utilities.Block)) error {
+	if err := miner.start(); err != nil {
+		return err
+	}
+	defer miner.stop()
+
+	for {
+		block, err := miner.mineBlock(ctx, miningRewardAddress, signingKey)
+		if err != nil {
+			return err
+		}
+		mined(*block)
+	}
+}
+
+func (miner *Miner) mineBlock(ctx context.Context, miningRewardAddress string, signingKey *ecdsa.PrivateKey) (*utilities.Block, error) {
+	for ctx.Err() == nil {
+		// The attempt is registered before the template is built, a tip that moves in between cancels it.
+		attemptCtx, cancel := context.WithCancel(ctx)
+		miner.mutex.Lock()
+		miner.cancelAttempt = cancel
+		miner.mutex.Unlock()
+
+		block, err := miner.mineTemplate(attemptCtx, miningRewardAddress, signingKey)
+		cancel()
+
+		switch {
+		case err == nil:
+			miner.mutex.Lock()
+			miner.status.BlocksMined++
+			miner.status.LastBlockHash = block.Hash
+			miner.mutex.Unlock()
+			return block, nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case errors.Is(err, context.Canceled), errors.Is(err, utilities.ErrStaleBlock):
+			miner.mutex.Lock()
+			miner.status.Restarts++
+			miner.mutex.Unlock()
+		default:
+			return nil, err
+		}
+	}
+
+	return nil, ctx.Err()
+}
+
+func (miner *Miner) mineTemplate(ctx context.Context, miningRewardAddress string, signingKey *ecdsa.PrivateKey) (*utilities.Block, error) {
+	transactions := miner.source.SelectTransactions(miner.options.TemplateSize)
+	block, err := miner.chain.NewBlockTemplate(transactions, miningRewardAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build a block template: %v", err)
+	}
+
+	miner.mutex.Lock()
+	miner.status.Height = block.Header.Height
+	miner.status.Transactions = len(transactions)
+	miner.mutex.Unlock()
+
+	if err := block.MineBlock(ctx, signingKey, miner.options.Workers, miner.report); err != nil {
+		return nil, err
+	}
+
+	if err := miner.chain.SubmitBlock(block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (miner *Miner) report(progress utilities.MiningProgress) {
+	miner.mutex.Lock()
+	defer miner.mutex.Unlock()
+
+	miner.status.Hashes += progress.Hashes
+}
+
+// restart abandons the block being mined, the mining loop picks up a fresh template.
+func (miner *Miner) restart() {
+	miner.mutex.Lock()
+	defer miner.mutex.Unlock()
+
+	if miner.cancelAttempt != nil {
+		miner.cancelAttempt()
+	}
+}
+
+func (miner *Miner) start() error {
+	miner.mutex.Lock()
+	defer miner.mutex.Unlock()
+
+	if miner.status.Mining {
+		return ErrAlreadyMining
+	}
+
+	miner.status = MiningStatus{Mining: true, StartedAt: time.Now()}
+	return nil
+}
+
+func (miner *Miner) stop() {
+	miner.mutex.Lock()
+	defer miner.mutex.Unlock()
+
+	if elapsed := time.Since(miner.status.StartedAt).Seconds(); elapsed > 0 {
+		miner.status.HashRate = float64(miner.status.Hashes) / elapsed
+	}
+	miner.status.Mining = false
+	miner.cancelAttempt = nil
+}
@@ -0,0 +1,240 @@
+package mining
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	utilities "bitshare-chain/infrastructure/utilities"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	errors "errors"
+	sync "sync"
+	testing "testing"
+	time "time"
+)
+
+const (
+	// unreachableBits is a target of one, no block template is ever mined.
+	unreachableBits uint32 = 0x01010000
+	// easyBits is met by one hash in 256.
+	easyBits uint32 = 0x2000ffff
+)
+
+// testChain builds templates on a chain that only exists in memory. submitErrors are returned by the next
+// SubmitBlock calls, in order, before blocks are accepted.
+type testChain struct {
+	mutex         sync.Mutex
+	bits          uint32
+	submitErrors  []error
+	submitted     []utilities.Block
+	templates     chan int64
+	blockHandlers []func(block utilities.Block)
+	reorgHandlers []func(event utilities.ReorgEvent)
+}
+
+func newTestChain(bits uint32) *testChain {
+	return &testChain{bits: bits, templates: make(chan int64, 100)}
+}
+
+func (chain *testChain) NewBlockTemplate(transactions []primitives.BlockTransaction, miningRewardAddress string) (*utilities.Block, error) {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	height := int64(len(chain.submitted)) + 1
+	chain.templates <- height
+	return utilities.NewBlock(height, time.Now(), transactions, "parent", miningRewardAddress, chain.bits), nil
+}
+
+func (chain *testChain) SubmitBlock(block *utilities.Block) error {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	if len(chain.submitErrors) > 0 {
+		err := chain.submitErrors[0]
+		chain.submitErrors = chain.submitErrors[1:]
+		return err
+	}
+
+	chain.submitted = append(chain.submitted, *block)
+	return nil
+}
+
+func (chain *testChain) SubscribeBlocks(handler func(block utilities.Block)) {
+	chain.blockHandlers = append(chain.blockHandlers, handler)
+}
+
+func (chain *testChain) SubscribeReorgs(handler func(event utilities.ReorgEvent)) {
+	chain.reorgHandlers = append(chain.reorgHandlers, handler)
+}
+
+type testSource struct {
+	handlers []func()
+}
+
+func (source *testSource) SelectTransactions(maxTransactions int) []primitives.BlockTransaction {
+	return nil
+}
+
+func (source *testSource) Subscribe(handler func()) {
+	source.handlers = append(source.handlers, handler)
+}
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, primitives.PublicKeyToAddress(&key.PublicKey)
+}
+
+func waitForTemplate(t *testing.T, chain *testChain) int64 {
+	t.Helper()
+
+	select {
+	case height := <-chain.templates:
+		return height
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block template was built")
+		return 0
+	}
+}
+
+func waitForRun(t *testing.T, done chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the miner did not stop")
+		return nil
+	}
+}
+
+func TestRunRestartsTheAttemptWhenTheTipOrTheMempoolChanges(t *testing.T) {
+	key, address := newTestKey(t)
+	chain, source := newTestChain(unreachableBits), &testSource{}
+	miner := NewMiner(chain, source, settings.MiningOptions{Workers: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- miner.Run(ctx, address, key, func(block utilities.Block) {})
+	}()
+	waitForTemplate(t, chain)
+
+	if _, err := miner.MineBlock(ctx, address, key); !errors.Is(err, ErrAlreadyMining) {
+		t.Fatalf("a second session returned %v, want %v", err, ErrAlreadyMining)
+	}
+
+	changes := map[string]func(){
+		"new block":      func() { chain.blockHandlers[0](utilities.Block{}) },
+		"reorganization": func() { chain.reorgHandlers[0](utilities.ReorgEvent{}) },
+		"new transaction": func() {
+			source.handlers[0]()
+		},
+	}
+	for _, name := range []string{"new block", "reorganization", "new transaction"} {
+		restarts := miner.Status().Restarts
+		changes[name]()
+
+		waitForTemplate(t, chain)
+		if status := miner.Status(); status.Restarts != restarts+1 || !status.Mining {
+			t.Fatalf("%s: got %d restarts while mining %v, want %d", name, status.Restarts, status.Mining, restarts+1)
+		}
+	}
+
+	cancel()
+	if err := waitForRun(t, done); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if status := miner.Status(); status.Mining || status.Restarts != 3 || status.BlocksMined != 0 {
+		t.Fatalf("got %+v after the session", status)
+	}
+}
+
+func TestRunStopsEveryWorkerWhenCancelled(t *testing.T) {
+	key, address := newTestKey(t)
+	chain := newTestChain(unreachableBits)
+	miner := NewMiner(chain, &testSource{}, settings.MiningOptions{Workers: 4})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- miner.Run(ctx, address, key, func(block utilities.Block) {})
+	}()
+	waitForTemplate(t, chain)
+
+	for deadline := time.Now().Add(5 * time.Second); miner.Status().Hashes == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the workers report no hashes")
+		}
+	}
+
+	cancel()
+	if err := waitForRun(t, done); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	// Run only returns once every worker returned, none of them reports hashes afterwards.
+	hashes := miner.Status().Hashes
+	time.Sleep(50 * time.Millisecond)
+	if status := miner.Status(); status.Hashes != hashes || status.Mining {
+		t.Fatalf("got %d hashes after %d when the session ended", status.Hashes, hashes)
+	}
+
+	// A change after the session has no attempt to cancel.
+	chain.blockHandlers[0](utilities.Block{})
+}
+
+func TestRunSubmitsMinedBlocksAndRetriesStaleOnes(t *testing.T) {
+	key, address := newTestKey(t)
+	chain := newTestChain(easyBits)
+	chain.submitErrors = []error{utilities.ErrStaleBlock}
+	miner := NewMiner(chain, &testSource{}, settings.MiningOptions{Workers: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mined := []utilities.Block{}
+	err := miner.Run(ctx, address, key, func(block utilities.Block) {
+		mined = append(mined, block)
+		if len(mined) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	if len(mined) != 2 || len(chain.submitted) != 2 {
+		t.Fatalf("mined %d blocks and submitted %d, want 2", len(mined), len(chain.submitted))
+	}
+	for index, block := range mined {
+		if block.Header.Height != int64(index)+1 || !block.Header.HasValidProofOfWork() || block.Hash != chain.submitted[index].Hash {
+			t.Fatalf("block %d: got %+v", index, block.Header)
+		}
+	}
+	if status := miner.Status(); status.BlocksMined != 2 || status.Restarts != 1 || status.LastBlockHash != mined[1].Hash {
+		t.Fatalf("got %+v, want 2 blocks and 1 restart", status)
+	}
+}
+
+func TestRunReturnsOtherSubmitErrors(t *testing.T) {
+	key, address := newTestKey(t)
+	chain := newTestChain(easyBits)
+	rejected := errors.New("rejected")
+	chain.submitErrors = []error{rejected}
+	miner := NewMiner(chain, &testSource{}, settings.MiningOptions{Workers: 1})
+
+	if err := miner.Run(context.Background(), address, key, func(block utilities.Block) {}); !errors.Is(err, rejected) {
+		t.Fatalf("got %v, want %v", err, rejected)
+	}
+	if miner.Status().Mining {
+		t.Fatal("the session is still mining")
+	}
+}
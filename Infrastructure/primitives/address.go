@@ -0,0 +1,129 @@
+package primitives
+
+import (
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	fmt "fmt"
+)
+
+// AddressVersion is the Base58Check version byte of every address, it makes addresses start with a 'B'.
+const AddressVersion byte = 0x19
+
+// AddressHashLength is the number of public key hash bytes an address carries.
+const AddressHashLength = 20
+
+// CompressedPublicKeyLength is the size of a compressed P-256 public key.
+const CompressedPublicKeyLength = 33
+
+var (
+	ErrInvalidAddress   = errors.New("invalid address")
+	ErrInvalidPublicKey = errors.New("invalid public key")
+)
+
+// Address identifies an account: the first 20 bytes of the SHA-256 of the compressed public key. Its string
+// form is the Base58Check encoding of AddressVersion and the hash, so mistyped addresses are detected.
+type Address struct {
+	Version byte
+	Hash    [AddressHashLength]byte
+}
+
+func NewAddress(publicKey *ecdsa.PublicKey) Address {
+	hash := sha256.Sum256(MarshalPublicKey(publicKey))
+
+	address := Address{Version: AddressVersion}
+	copy(address.Hash[:], hash[:AddressHashLength])
+	return address
+}
+
+func (address Address) String() string {
+	return Base58CheckEncode(address.Version, address.Hash[:])
+}
+
+// ParseAddress decodes an address and checks its checksum, version and length.
+func ParseAddress(value string) (Address, error) {
+	version, payload, err := Base58CheckDecode(value)
+	if err != nil {
+		return Address{}, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+	}
+
+	if version != AddressVersion {
+		return Address{}, fmt.Errorf("%w: unknown version %d", ErrInvalidAddress, version)
+	}
+
+	if len(payload) != AddressHashLength {
+		return Address{}, fmt.Errorf("%w: expected a %d byte hash, got %d", ErrInvalidAddress, AddressHashLength, len(payload))
+	}
+
+	address := Address{Version: version}
+	copy(address.Hash[:], payload)
+	return address, nil
+}
+
+func ValidateAddress(value string) error {
+	_, err := ParseAddress(value)
+	return err
+}
+
+// PublicKeyToAddress returns the string form of the address owned by the key.
+func PublicKeyToAddress(publicKey *ecdsa.PublicKey) string {
+	return NewAddress(publicKey).String()
+}
+
+// PublicKeyHexToAddress returns the address of a hex encoded public key in compressed or uncompressed form.
+func PublicKeyHexToAddress(publicKeyHex string) (string, error) {
+	publicKey, err := ConvertFromHexString(publicKeyHex)
+	if err != nil {
+		return "", err
+	}
+
+	return PublicKeyToAddress(&publicKey), nil
+}
+
+// MarshalPublicKey returns the compressed encoding of the key, which transactions and headers carry.
+func MarshalPublicKey(publicKey *ecdsa.PublicKey) []byte {
+	return elliptic.MarshalCompressed(elliptic.P256(), publicKey.X, publicKey.Y)
+}
+
+func ConvertFromHexString(hexString string) (ecdsa.PublicKey, error) {
+	decoded, err := hex.DecodeString(hexString)
+	if err != nil {
+		return ecdsa.PublicKey{}, err
+	}
+
+	return ConvertFromBytes(decoded)
+}
+
+// ConvertFromBytes parses a compressed or uncompressed P-256 public key.
+func ConvertFromBytes(data []byte) (ecdsa.PublicKey, error) {
+	x, y := elliptic.Unmarshal(elliptic.P256(), data)
+	if x == nil {
+		x, y = elliptic.UnmarshalCompressed(elliptic.P256(), data)
+	}
+
+	if x == nil {
+		return ecdsa.PublicKey{}, ErrInvalidPublicKey
+	}
+
+	return ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+// VerifyPublicKeyOwnsAddress parses a compressed public key and checks that it belongs to the address.
+func VerifyPublicKeyOwnsAddress(publicKeyBytes []byte, address string) (*ecdsa.PublicKey, error) {
+	if len(publicKeyBytes) != CompressedPublicKeyLength {
+		return nil, fmt.Errorf("%w: expected a %d byte compressed key", ErrInvalidPublicKey, CompressedPublicKeyLength)
+	}
+
+	publicKey, err := ConvertFromBytes(publicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if PublicKeyToAddress(&publicKey) != address {
+		return nil, fmt.Errorf("%w: public key does not belong to %s", ErrInvalidAddress, address)
+	}
+
+	return &publicKey, nil
+}
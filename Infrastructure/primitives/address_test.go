@@ -0,0 +1,96 @@
+package primitives
+
+import (
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	strings "strings"
+	testing "testing"
+)
+
+func newTestPublicKey(t *testing.T) *ecdsa.PublicKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &key.PublicKey
+}
+
+func TestPublicKeyToAddress(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		publicKey := newTestPublicKey(t)
+		address := PublicKeyToAddress(publicKey)
+
+		hash := sha256.Sum256(elliptic.MarshalCompressed(elliptic.P256(), publicKey.X, publicKey.Y))
+		if expected := Base58CheckEncode(AddressVersion, hash[:AddressHashLength]); address != expected {
+			t.Fatalf("got %s, want %s", address, expected)
+		}
+		if !strings.HasPrefix(address, "B") {
+			t.Fatalf("%s does not start with a 'B'", address)
+		}
+
+		parsed, err := ParseAddress(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != NewAddress(publicKey) || parsed.String() != address {
+			t.Fatalf("%s does not round trip", address)
+		}
+
+		// Stored public keys were hex encoded in either form, both give the same address.
+		for _, encoded := range [][]byte{MarshalPublicKey(publicKey), elliptic.Marshal(elliptic.P256(), publicKey.X, publicKey.Y)} {
+			fromHex, err := PublicKeyHexToAddress(hex.EncodeToString(encoded))
+			if err != nil || fromHex != address {
+				t.Fatalf("the %d byte key gives %q, %v, want %s", len(encoded), fromHex, err, address)
+			}
+		}
+	}
+}
+
+func TestParseAddressRejectsInvalidAddresses(t *testing.T) {
+	address := []byte(PublicKeyToAddress(newTestPublicKey(t)))
+	flipped := append([]byte{}, address...)
+	if flipped[len(flipped)-1] == 'z' {
+		flipped[len(flipped)-1] = 'y'
+	} else {
+		flipped[len(flipped)-1] = 'z'
+	}
+
+	tests := map[string]string{
+		"empty":               "",
+		"bad checksum":        string(flipped),
+		"bitcoin version":     Base58CheckEncode(0x00, make([]byte, AddressHashLength)),
+		"short hash":          Base58CheckEncode(AddressVersion, make([]byte, AddressHashLength-1)),
+		"long hash":           Base58CheckEncode(AddressVersion, make([]byte, AddressHashLength+1)),
+		"outside of alphabet": "0" + string(address[1:]),
+		"hex public key":      hex.EncodeToString(MarshalPublicKey(newTestPublicKey(t))),
+	}
+
+	for name, value := range tests {
+		if _, err := ParseAddress(value); !errors.Is(err, ErrInvalidAddress) {
+			t.Fatalf("%s: got %v, want %v", name, err, ErrInvalidAddress)
+		}
+	}
+}
+
+func TestVerifyPublicKeyOwnsAddress(t *testing.T) {
+	publicKey := newTestPublicKey(t)
+	address := PublicKeyToAddress(publicKey)
+
+	verified, err := VerifyPublicKeyOwnsAddress(MarshalPublicKey(publicKey), address)
+	if err != nil || !verified.Equal(publicKey) {
+		t.Fatalf("the owner does not verify: %v", err)
+	}
+
+	if _, err := VerifyPublicKeyOwnsAddress(MarshalPublicKey(newTestPublicKey(t)), address); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("another key returned %v, want %v", err, ErrInvalidAddress)
+	}
+	if _, err := VerifyPublicKeyOwnsAddress(elliptic.Marshal(elliptic.P256(), publicKey.X, publicKey.Y), address); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Fatalf("an uncompressed key returned %v, want %v", err, ErrInvalidPublicKey)
+	}
+}
@@ -0,0 +1,152 @@
+package primitives
+
+import (
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	math "math"
+	strconv "strconv"
+	strings "strings"
+)
+
+// AmountDecimals is the number of fractional digits a coin can be split into.
+const AmountDecimals = 8
+
+// AmountUnitsPerCoin is the number of smallest units in one coin.
+const AmountUnitsPerCoin Amount = 100_000_000
+
+var (
+	ErrAmountOverflow = errors.New("amount overflow")
+	ErrInvalidAmount  = errors.New("invalid amount")
+)
+
+// Amount is a coin value expressed as an integer count of the smallest unit.
+// It is serialized as a decimal string in JSON. Mongo stores it as an int64 through the codec the data access
+// layer registers, this package stays free of storage dependencies.
+type Amount int64
+
+func NewAmountFromCoins(coins int64) (Amount, error) {
+	return Amount(coins).Mul(int64(AmountUnitsPerCoin))
+}
+
+// ParseAmount strictly parses a non-negative decimal coin value such as "12" or "0.00000001".
+// Signs, exponents, white space, redundant leading zeros and more than AmountDecimals
+// fractional digits are rejected.
+func ParseAmount(value string) (Amount, error) {
+	integerPart, fractionPart, hasFraction := strings.Cut(value, ".")
+
+	if integerPart == "" || (hasFraction && fractionPart == "") || len(fractionPart) > AmountDecimals {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	if len(integerPart) > 1 && integerPart[0] == '0' {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	if !isDecimalDigits(integerPart) || !isDecimalDigits(fractionPart) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	coins, err := strconv.ParseInt(integerPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrAmountOverflow, value)
+	}
+
+	amount, err := NewAmountFromCoins(coins)
+	if err != nil {
+		return 0, err
+	}
+
+	if fractionPart == "" {
+		return amount, nil
+	}
+
+	fraction, err := strconv.ParseInt(fractionPart+strings.Repeat("0", AmountDecimals-len(fractionPart)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	return amount.Add(Amount(fraction))
+}
+
+func (amount Amount) Add(other Amount) (Amount, error) {
+	if (other > 0 && amount > math.MaxInt64-other) || (other < 0 && amount < math.MinInt64-other) {
+		return 0, ErrAmountOverflow
+	}
+	return amount + other, nil
+}
+
+func (amount Amount) Sub(other Amount) (Amount, error) {
+	if (other < 0 && amount > math.MaxInt64+other) || (other > 0 && amount < math.MinInt64+other) {
+		return 0, ErrAmountOverflow
+	}
+	return amount - other, nil
+}
+
+func (amount Amount) Mul(factor int64) (Amount, error) {
+	if amount == 0 || factor == 0 {
+		return 0, nil
+	}
+
+	result := int64(amount) * factor
+	if result/factor != int64(amount) || (amount == -1 && factor == math.MinInt64) || (factor == -1 && amount == math.MinInt64) {
+		return 0, ErrAmountOverflow
+	}
+	return Amount(result), nil
+}
+
+func (amount Amount) IsPositive() bool {
+	return amount > 0
+}
+
+func (amount Amount) IsNegative() bool {
+	return amount < 0
+}
+
+// String formats the amount in coins without trailing fractional zeros, e.g. "12.5".
+func (amount Amount) String() string {
+	units := uint64(amount)
+	sign := ""
+	if amount < 0 {
+		units = uint64(-(amount + 1)) + 1
+		sign = "-"
+	}
+
+	coins := units / uint64(AmountUnitsPerCoin)
+	fraction := units % uint64(AmountUnitsPerCoin)
+	if fraction == 0 {
+		return sign + strconv.FormatUint(coins, 10)
+	}
+
+	fractionString := strings.TrimRight(fmt.Sprintf("%0*d", AmountDecimals, fraction), "0")
+	return sign + strconv.FormatUint(coins, 10) + "." + fractionString
+}
+
+func (amount Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(amount.String())
+}
+
+// UnmarshalJSON only accepts a JSON string, so that values never pass through a float.
+func (amount *Amount) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: amounts must be JSON strings", ErrInvalidAmount)
+	}
+
+	parsed, err := ParseAmount(value)
+	if err != nil {
+		return err
+	}
+
+	*amount = parsed
+	return nil
+}
+
+func isDecimalDigits(value string) bool {
+	for _, character := range value {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+	return true
+}
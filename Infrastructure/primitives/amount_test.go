@@ -0,0 +1,140 @@
+package primitives
+
+import (
+	json "encoding/json"
+	errors "errors"
+	math "math"
+	testing "testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Amount
+	}{
+		{"0", 0},
+		{"0.00000001", 1},
+		{"1", AmountUnitsPerCoin},
+		{"12.5", 1_250_000_000},
+		{"12.50000000", 1_250_000_000},
+		{"92233720368.54775807", math.MaxInt64},
+	}
+
+	for _, test := range tests {
+		amount, err := ParseAmount(test.value)
+		if err != nil || amount != test.expected {
+			t.Fatalf("%q: got %d, %v, want %d", test.value, amount, err, test.expected)
+		}
+	}
+}
+
+func TestParseAmountRejectsInvalidValues(t *testing.T) {
+	tests := map[string]error{
+		"":                     ErrInvalidAmount,
+		".5":                   ErrInvalidAmount,
+		"5.":                   ErrInvalidAmount,
+		"1e8":                  ErrInvalidAmount,
+		"1E-8":                 ErrInvalidAmount,
+		"0.000000001":          ErrInvalidAmount,
+		"-1":                   ErrInvalidAmount,
+		"+1":                   ErrInvalidAmount,
+		"-0":                   ErrInvalidAmount,
+		"1.-5":                 ErrInvalidAmount,
+		" 1":                   ErrInvalidAmount,
+		"1 ":                   ErrInvalidAmount,
+		"01":                   ErrInvalidAmount,
+		"1,5":                  ErrInvalidAmount,
+		"0x10":                 ErrInvalidAmount,
+		"NaN":                  ErrInvalidAmount,
+		"92233720368.54775808": ErrAmountOverflow,
+		"92233720369":          ErrAmountOverflow,
+		"9223372036854775808":  ErrAmountOverflow,
+		"99999999999999999999": ErrAmountOverflow,
+	}
+
+	for value, expected := range tests {
+		if amount, err := ParseAmount(value); !errors.Is(err, expected) {
+			t.Fatalf("%q: got %d, %v, want %v", value, amount, err, expected)
+		}
+	}
+}
+
+func TestAmountArithmeticOverflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation func() (Amount, error)
+		expected  Amount
+		overflow  bool
+	}{
+		{"add", func() (Amount, error) { return Amount(2).Add(3) }, 5, false},
+		{"add to max", func() (Amount, error) { return Amount(math.MaxInt64 - 1).Add(1) }, math.MaxInt64, false},
+		{"add past max", func() (Amount, error) { return Amount(math.MaxInt64).Add(1) }, 0, true},
+		{"add past min", func() (Amount, error) { return Amount(math.MinInt64).Add(-1) }, 0, true},
+		{"sub", func() (Amount, error) { return Amount(2).Sub(3) }, -1, false},
+		{"sub to min", func() (Amount, error) { return Amount(-1).Sub(math.MaxInt64) }, math.MinInt64, false},
+		{"sub past min", func() (Amount, error) { return Amount(math.MinInt64).Sub(1) }, 0, true},
+		{"sub past max", func() (Amount, error) { return Amount(0).Sub(math.MinInt64) }, 0, true},
+		{"mul", func() (Amount, error) { return Amount(-3).Mul(4) }, -12, false},
+		{"mul by zero", func() (Amount, error) { return Amount(math.MinInt64).Mul(0) }, 0, false},
+		{"mul past max", func() (Amount, error) { return Amount(math.MaxInt64/2 + 1).Mul(2) }, 0, true},
+		{"mul min by minus one", func() (Amount, error) { return Amount(math.MinInt64).Mul(-1) }, 0, true},
+		{"mul minus one by min", func() (Amount, error) { return Amount(-1).Mul(math.MinInt64) }, 0, true},
+		{"coins past max", func() (Amount, error) { return NewAmountFromCoins(math.MaxInt64/int64(AmountUnitsPerCoin) + 1) }, 0, true},
+	}
+
+	for _, test := range tests {
+		amount, err := test.operation()
+		if test.overflow {
+			if !errors.Is(err, ErrAmountOverflow) {
+				t.Fatalf("%s: got %d, %v, want %v", test.name, amount, err, ErrAmountOverflow)
+			}
+			continue
+		}
+		if err != nil || amount != test.expected {
+			t.Fatalf("%s: got %d, %v, want %d", test.name, amount, err, test.expected)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := map[Amount]string{
+		0:                  "0",
+		1:                  "0.00000001",
+		AmountUnitsPerCoin: "1",
+		1_250_000_000:      "12.5",
+		-1:                 "-0.00000001",
+		-1_250_000_000:     "-12.5",
+		math.MaxInt64:      "92233720368.54775807",
+		math.MinInt64:      "-92233720368.54775808",
+	}
+
+	for amount, expected := range tests {
+		if amount.String() != expected {
+			t.Fatalf("%d: got %q, want %q", int64(amount), amount.String(), expected)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	for _, amount := range []Amount{0, 1, AmountUnitsPerCoin, 1_250_000_000, math.MaxInt64} {
+		data, err := json.Marshal(amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `"` + amount.String() + `"`; string(data) != expected {
+			t.Fatalf("got %s, want %s", data, expected)
+		}
+
+		var decoded Amount
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded != amount {
+			t.Fatalf("%s: got %d, %v, want %d", data, decoded, err, amount)
+		}
+	}
+
+	for _, data := range []string{`12.5`, `1`, `null`, `"1e8"`, `"-1"`} {
+		var decoded Amount
+		if err := json.Unmarshal([]byte(data), &decoded); !errors.Is(err, ErrInvalidAmount) {
+			t.Fatalf("%s: got %v, want %v", data, err, ErrInvalidAmount)
+		}
+	}
+}
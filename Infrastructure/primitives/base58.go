@@ -0,0 +1,104 @@
+package primitives
+
+import (
+	bytes "bytes"
+	sha256 "crypto/sha256"
+	errors "errors"
+	big "math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58ChecksumLength is the number of double SHA-256 bytes appended by Base58Check.
+const base58ChecksumLength = 4
+
+var (
+	ErrInvalidBase58    = errors.New("invalid base58 string")
+	ErrInvalidChecksum  = errors.New("checksum does not match")
+	base58Radix         = big.NewInt(58)
+	base58AlphabetIndex = func() [256]int {
+		var index [256]int
+		for position := range index {
+			index[position] = -1
+		}
+		for position := 0; position < len(base58Alphabet); position++ {
+			index[base58Alphabet[position]] = position
+		}
+		return index
+	}()
+)
+
+// Base58Encode encodes data with the Bitcoin alphabet, every leading zero byte becomes a leading '1'.
+func Base58Encode(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	value := new(big.Int).SetBytes(data)
+	remainder := new(big.Int)
+	encoded := []byte{}
+	for value.Sign() > 0 {
+		value.DivMod(value, base58Radix, remainder)
+		encoded = append(encoded, base58Alphabet[remainder.Int64()])
+	}
+
+	for index := 0; index < zeros; index++ {
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for left, right := 0, len(encoded)-1; left < right; left, right = left+1, right-1 {
+		encoded[left], encoded[right] = encoded[right], encoded[left]
+	}
+	return string(encoded)
+}
+
+func Base58Decode(encoded string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(encoded) && encoded[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	value := new(big.Int)
+	for index := 0; index < len(encoded); index++ {
+		digit := base58AlphabetIndex[encoded[index]]
+		if digit < 0 {
+			return nil, ErrInvalidBase58
+		}
+		value.Mul(value, base58Radix)
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	return append(make([]byte, zeros), value.Bytes()...), nil
+}
+
+// Base58CheckEncode encodes the version byte and the payload followed by a four byte double SHA-256 checksum.
+func Base58CheckEncode(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	return Base58Encode(append(data, base58Checksum(data)...))
+}
+
+// Base58CheckDecode reverses Base58CheckEncode after verifying the checksum.
+func Base58CheckDecode(encoded string) (byte, []byte, error) {
+	data, err := Base58Decode(encoded)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(data) < 1+base58ChecksumLength {
+		return 0, nil, ErrInvalidBase58
+	}
+
+	body, checksum := data[:len(data)-base58ChecksumLength], data[len(data)-base58ChecksumLength:]
+	if !bytes.Equal(base58Checksum(body), checksum) {
+		return 0, nil, ErrInvalidChecksum
+	}
+
+	return body[0], body[1:], nil
+}
+
+func base58Checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:base58ChecksumLength]
+}
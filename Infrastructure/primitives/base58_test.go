@@ -0,0 +1,92 @@
+package primitives
+
+import (
+	hex "encoding/hex"
+	errors "errors"
+	testing "testing"
+)
+
+// Vectors of Bitcoin Core's base58_encode_decode.json.
+var base58Vectors = []struct {
+	data    string
+	encoded string
+}{
+	{data: "", encoded: ""},
+	{data: "61", encoded: "2g"},
+	{data: "626262", encoded: "a3gV"},
+	{data: "636363", encoded: "aPEr"},
+	{data: "73696d706c792061206c6f6e6720737472696e67", encoded: "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+	{data: "00eb15231dfceb60925886b67d065299925915aeb172c06647", encoded: "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+	{data: "516b6fcd0f", encoded: "ABnLTmg"},
+	{data: "bf4f89001e670274dd", encoded: "3SEo3LWLoPntC"},
+	{data: "572e4794", encoded: "3EFU7m"},
+	{data: "ecac89cad93923c02321", encoded: "EJDM8drfXA6uyA"},
+	{data: "10c8511e", encoded: "Rt5zm"},
+	{data: "00000000000000000000", encoded: "1111111111"},
+}
+
+func TestBase58Vectors(t *testing.T) {
+	for _, vector := range base58Vectors {
+		data, _ := hex.DecodeString(vector.data)
+
+		if encoded := Base58Encode(data); encoded != vector.encoded {
+			t.Fatalf("%s encodes to %q, want %q", vector.data, encoded, vector.encoded)
+		}
+
+		decoded, err := Base58Decode(vector.encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(decoded) != vector.data {
+			t.Fatalf("%q decodes to %x, want %s", vector.encoded, decoded, vector.data)
+		}
+	}
+}
+
+func TestBase58DecodeRejectsCharactersOutsideTheAlphabet(t *testing.T) {
+	for _, encoded := range []string{"0", "O", "I", "l", "2g ", "a3g+"} {
+		if _, err := Base58Decode(encoded); !errors.Is(err, ErrInvalidBase58) {
+			t.Fatalf("%q: got %v, want %v", encoded, err, ErrInvalidBase58)
+		}
+	}
+}
+
+func TestBase58CheckVectors(t *testing.T) {
+	tests := []struct {
+		version byte
+		payload string
+		encoded string
+	}{
+		{version: 0x00, payload: "010966776006953d5567439e5e39f86a0d273bee", encoded: "16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM"},
+		// Leading zero bytes of the payload stay leading zeros, not only the version byte becomes a '1'.
+		{version: 0x00, payload: "0000000000000000000000000000000000000000", encoded: "1111111111111111111114oLvT2"},
+	}
+
+	for _, test := range tests {
+		payload, _ := hex.DecodeString(test.payload)
+
+		if encoded := Base58CheckEncode(test.version, payload); encoded != test.encoded {
+			t.Fatalf("%s encodes to %q, want %q", test.payload, encoded, test.encoded)
+		}
+
+		version, decoded, err := Base58CheckDecode(test.encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != test.version || hex.EncodeToString(decoded) != test.payload {
+			t.Fatalf("%q decodes to version %d and %x", test.encoded, version, decoded)
+		}
+	}
+}
+
+func TestBase58CheckDecodeRejectsBadChecksums(t *testing.T) {
+	encoded := []byte("16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM")
+	encoded[len(encoded)-1] = 'N'
+
+	if _, _, err := Base58CheckDecode(string(encoded)); !errors.Is(err, ErrInvalidChecksum) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidChecksum)
+	}
+	if _, _, err := Base58CheckDecode("2g"); !errors.Is(err, ErrInvalidBase58) {
+		t.Fatalf("a string shorter than a checksum returned %v, want %v", err, ErrInvalidBase58)
+	}
+}
@@ -0,0 +1,183 @@
+package primitives
+
+import (
+	ecdsa "crypto/ecdsa"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	fmt "fmt"
+	math "math"
+)
+
+// TransactionKind tells transfers between accounts apart from the transactions that create coins.
+type TransactionKind uint8
+
+const (
+	// TransactionKindTransfer moves an amount from a signing sender to a recipient.
+	TransactionKindTransfer TransactionKind = iota
+	// TransactionKindCoinbase pays the block reward and fees and has to come first in every block.
+	TransactionKindCoinbase
+	// TransactionKindAllocation credits a genesis allocation and only exists in the genesis block.
+	TransactionKindAllocation
+)
+
+// BlockTransaction is the only transaction type that is hashed, signed and verified.
+// Mongo documents and API binding models are plain data and map to and from it.
+// A transfer carries the sender's compressed PublicKey, the address only holds its hash.
+type BlockTransaction struct {
+	Kind        TransactionKind
+	FromAddress string
+	PublicKey   []byte
+	ToAddress   string
+	Amount      Amount
+	Fee         Amount
+	Nonce       uint64
+	Signature   []byte
+}
+
+// NewBlockTransaction creates an unsigned transaction. The nonce is the sender's sequence
+// number and has to match the mempool's next nonce when the transaction is added. The fee
+// is paid by the sender on top of the amount and collected by the miner of the block.
+func NewBlockTransaction(fromAddress, toAddress string, amount Amount, fee Amount, nonce uint64) *BlockTransaction {
+	return &BlockTransaction{
+		FromAddress: fromAddress,
+		ToAddress:   toAddress,
+		Amount:      amount,
+		Fee:         fee,
+		Nonce:       nonce,
+	}
+}
+
+// TotalCost is what the sender's balance is charged, the amount plus the fee.
+func (transaction *BlockTransaction) TotalCost() (Amount, error) {
+	return transaction.Amount.Add(transaction.Fee)
+}
+
+// Size is the length of the canonical encoding in bytes, fee rates are measured against it.
+func (transaction *BlockTransaction) Size() int {
+	return len(transaction.MarshalCanonical())
+}
+
+// SignedSize is the Size of the transaction once it is signed, signatures always have SignatureLength bytes.
+func (transaction *BlockTransaction) SignedSize() int {
+	signed := *transaction
+	signed.Signature = make([]byte, SignatureLength)
+	return signed.Size()
+}
+
+// SignTransaction attaches the public key and signs the canonical transaction hash with the key that owns FromAddress.
+func (transaction *BlockTransaction) SignTransaction(signingKey *ecdsa.PrivateKey) error {
+	if PublicKeyToAddress(&signingKey.PublicKey) != transaction.FromAddress {
+		return errors.New("you cannot sign transactions for other wallets")
+	}
+
+	transaction.PublicKey = MarshalPublicKey(&signingKey.PublicKey)
+
+	signature, err := SignHash(signingKey, transaction.CalculateHash())
+	if err != nil {
+		return err
+	}
+
+	transaction.Signature = signature
+	return nil
+}
+
+// IsValid verifies the signature of a transfer. Coinbase and allocation transactions are never valid
+// on their own, they are checked as part of their block.
+func (transaction *BlockTransaction) IsValid() bool {
+	return transaction.Verify() == nil
+}
+
+// Verify checks what IsValid checks and tells why a transaction is rejected.
+func (transaction *BlockTransaction) Verify() error {
+	if transaction.Kind != TransactionKindTransfer || transaction.FromAddress == "" {
+		return errors.New("only transfers with a sender can be verified on their own")
+	}
+
+	if err := ValidateAddress(transaction.ToAddress); err != nil {
+		return err
+	}
+
+	if len(transaction.Signature) != SignatureLength {
+		return fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidSignature, SignatureLength, len(transaction.Signature))
+	}
+
+	publicKey, err := VerifyPublicKeyOwnsAddress(transaction.PublicKey, transaction.FromAddress)
+	if err != nil {
+		return err
+	}
+
+	if !VerifySignature(publicKey, transaction.CalculateHash(), transaction.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (transaction *BlockTransaction) CalculateHash() []byte {
+	hash := sha256.Sum256(transaction.SigningBytes())
+	return hash[:]
+}
+
+// Id is the SHA-256 of the full canonical encoding, signature included. It identifies the
+// transaction in merkle trees and inclusion proofs.
+func (transaction *BlockTransaction) Id() []byte {
+	hash := sha256.Sum256(transaction.MarshalCanonical())
+	return hash[:]
+}
+
+// TransactionId returns the hex encoded Id.
+func (transaction *BlockTransaction) TransactionId() string {
+	return hex.EncodeToString(transaction.Id())
+}
+
+// SigningBytes returns the canonical encoding of everything the signature covers.
+func (transaction *BlockTransaction) SigningBytes() []byte {
+	encoder := NewCanonicalEncoder(CanonicalTransactionTag)
+	transaction.writeSigningFields(encoder)
+	return encoder.Bytes()
+}
+
+// MarshalCanonical returns the canonical encoding of the signed transaction, used on the wire and inside blocks.
+func (transaction *BlockTransaction) MarshalCanonical() []byte {
+	encoder := NewCanonicalEncoder(CanonicalTransactionTag)
+	transaction.writeSigningFields(encoder)
+	encoder.WriteBytes(transaction.Signature)
+	return encoder.Bytes()
+}
+
+func UnmarshalCanonicalTransaction(data []byte) (BlockTransaction, error) {
+	decoder := NewCanonicalDecoder(data, CanonicalTransactionTag)
+	kind := decoder.ReadUint32()
+	transaction := BlockTransaction{
+		Kind:        TransactionKind(kind),
+		FromAddress: decoder.ReadString(),
+		PublicKey:   decoder.ReadBytes(),
+		ToAddress:   decoder.ReadString(),
+		Amount:      Amount(decoder.ReadInt64()),
+		Fee:         Amount(decoder.ReadInt64()),
+		Nonce:       decoder.ReadUint64(),
+		Signature:   decoder.ReadBytes(),
+	}
+
+	if err := decoder.Finish(); err != nil {
+		return BlockTransaction{}, err
+	}
+
+	// The kind is written as a uint32. Larger values would truncate to a valid kind and give a transaction a
+	// second encoding, and with it a second id.
+	if kind > math.MaxUint8 {
+		return BlockTransaction{}, fmt.Errorf("transaction kind %d is out of range", kind)
+	}
+
+	return transaction, nil
+}
+
+func (transaction *BlockTransaction) writeSigningFields(encoder *CanonicalEncoder) {
+	encoder.WriteUint32(uint32(transaction.Kind))
+	encoder.WriteString(transaction.FromAddress)
+	encoder.WriteBytes(transaction.PublicKey)
+	encoder.WriteString(transaction.ToAddress)
+	encoder.WriteInt64(int64(transaction.Amount))
+	encoder.WriteInt64(int64(transaction.Fee))
+	encoder.WriteUint64(transaction.Nonce)
+}
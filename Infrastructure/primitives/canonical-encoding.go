@@ -0,0 +1,169 @@
+package primitives
+
+import (
+	bytes "bytes"
+	binary "encoding/binary"
+	errors "errors"
+	fmt "fmt"
+	time "time"
+)
+
+// CanonicalEncodingVersion is written as the first byte of every canonical encoding.
+// Bump it whenever the layout of an encoded type changes.
+const CanonicalEncodingVersion byte = 1
+
+// Type tags follow the version byte so that a transaction can never be mistaken for a block.
+const (
+	CanonicalTransactionTag byte = 0x01
+	CanonicalBlockTag       byte = 0x02
+	CanonicalBlockHeaderTag byte = 0x03
+)
+
+var ErrCanonicalEncodingTruncated = errors.New("canonical encoding is truncated")
+
+// CanonicalEncoder writes the single byte layout used for hashing, signing and wire transfer.
+// Variable length fields are prefixed with their length as a big endian uint32, integers are
+// written as fixed size big endian values and time stamps as Unix milliseconds.
+type CanonicalEncoder struct {
+	buffer bytes.Buffer
+}
+
+func NewCanonicalEncoder(tag byte) *CanonicalEncoder {
+	encoder := &CanonicalEncoder{}
+	encoder.buffer.WriteByte(CanonicalEncodingVersion)
+	encoder.buffer.WriteByte(tag)
+	return encoder
+}
+
+func (encoder *CanonicalEncoder) WriteBytes(value []byte) {
+	encoder.WriteUint32(uint32(len(value)))
+	encoder.buffer.Write(value)
+}
+
+func (encoder *CanonicalEncoder) WriteString(value string) {
+	encoder.WriteBytes([]byte(value))
+}
+
+func (encoder *CanonicalEncoder) WriteUint32(value uint32) {
+	var buffer [4]byte
+	binary.BigEndian.PutUint32(buffer[:], value)
+	encoder.buffer.Write(buffer[:])
+}
+
+func (encoder *CanonicalEncoder) WriteUint64(value uint64) {
+	var buffer [8]byte
+	binary.BigEndian.PutUint64(buffer[:], value)
+	encoder.buffer.Write(buffer[:])
+}
+
+func (encoder *CanonicalEncoder) WriteInt64(value int64) {
+	encoder.WriteUint64(uint64(value))
+}
+
+// WriteTime only keeps millisecond precision, which is what Mongo stores.
+func (encoder *CanonicalEncoder) WriteTime(value time.Time) {
+	encoder.WriteInt64(value.UnixMilli())
+}
+
+func (encoder *CanonicalEncoder) Bytes() []byte {
+	return encoder.buffer.Bytes()
+}
+
+// CanonicalDecoder reads values written by CanonicalEncoder. The first error sticks,
+// so callers can read a whole structure and call Finish once at the end.
+type CanonicalDecoder struct {
+	data []byte
+	err  error
+}
+
+func NewCanonicalDecoder(data []byte, tag byte) *CanonicalDecoder {
+	decoder := &CanonicalDecoder{data: data}
+
+	header := decoder.read(2)
+	if decoder.err != nil {
+		return decoder
+	}
+
+	if header[0] != CanonicalEncodingVersion {
+		decoder.err = fmt.Errorf("unsupported canonical encoding version %d", header[0])
+	} else if header[1] != tag {
+		decoder.err = fmt.Errorf("unexpected canonical type tag %d, expected %d", header[1], tag)
+	}
+
+	return decoder
+}
+
+func (decoder *CanonicalDecoder) ReadBytes() []byte {
+	length := decoder.ReadUint32()
+	if decoder.err != nil {
+		return nil
+	}
+
+	if uint64(length) > uint64(len(decoder.data)) {
+		decoder.err = ErrCanonicalEncodingTruncated
+		return nil
+	}
+
+	value := decoder.read(int(length))
+	if len(value) == 0 {
+		return nil
+	}
+
+	return append([]byte{}, value...)
+}
+
+func (decoder *CanonicalDecoder) ReadString() string {
+	return string(decoder.ReadBytes())
+}
+
+func (decoder *CanonicalDecoder) ReadUint32() uint32 {
+	value := decoder.read(4)
+	if decoder.err != nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(value)
+}
+
+func (decoder *CanonicalDecoder) ReadUint64() uint64 {
+	value := decoder.read(8)
+	if decoder.err != nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(value)
+}
+
+func (decoder *CanonicalDecoder) ReadInt64() int64 {
+	return int64(decoder.ReadUint64())
+}
+
+func (decoder *CanonicalDecoder) ReadTime() time.Time {
+	return time.UnixMilli(decoder.ReadInt64()).UTC()
+}
+
+// Finish reports the first decoding error, or an error when bytes are left over.
+func (decoder *CanonicalDecoder) Finish() error {
+	if decoder.err != nil {
+		return decoder.err
+	}
+
+	if len(decoder.data) != 0 {
+		return fmt.Errorf("%d trailing bytes after canonical encoding", len(decoder.data))
+	}
+
+	return nil
+}
+
+func (decoder *CanonicalDecoder) read(length int) []byte {
+	if decoder.err != nil {
+		return nil
+	}
+
+	if len(decoder.data) < length {
+		decoder.err = ErrCanonicalEncodingTruncated
+		return nil
+	}
+
+	value := decoder.data[:length]
+	decoder.data = decoder.data[length:]
+	return value
+}
@@ -0,0 +1,113 @@
+package primitives
+
+import (
+	hex "encoding/hex"
+	errors "errors"
+	reflect "reflect"
+	testing "testing"
+)
+
+// goldenTransactionHex is the canonical encoding of goldenTransaction. It must only change together with
+// CanonicalEncodingVersion.
+const goldenTransactionHex = "" +
+	"01" + "01" + // version, transaction tag
+	"00000000" + // kind: transfer
+	"00000006" + "73656e646572" + // from address: "sender"
+	"00000002" + "0203" + // public key
+	"00000009" + "726563697069656e74" + // to address: "recipient"
+	"0000000008f0d180" + // amount: 1.5 coins
+	"00000000000003e8" + // fee: 1000 units
+	"0000000000000007" + // nonce
+	"00000003" + "040506" // signature
+
+func goldenTransaction() BlockTransaction {
+	return BlockTransaction{
+		Kind:        TransactionKindTransfer,
+		FromAddress: "sender",
+		PublicKey:   []byte{0x02, 0x03},
+		ToAddress:   "recipient",
+		Amount:      150_000_000,
+		Fee:         1000,
+		Nonce:       7,
+		Signature:   []byte{0x04, 0x05, 0x06},
+	}
+}
+
+func TestTransactionCanonicalEncodingGolden(t *testing.T) {
+	transaction := goldenTransaction()
+
+	encoded := hex.EncodeToString(transaction.MarshalCanonical())
+	if encoded != goldenTransactionHex {
+		t.Fatalf("encoding changed\n got: %s\nwant: %s", encoded, goldenTransactionHex)
+	}
+
+	// The signing bytes are the encoding without the trailing signature.
+	signingBytes := hex.EncodeToString(transaction.SigningBytes())
+	if want := goldenTransactionHex[:len(goldenTransactionHex)-len("00000003040506")]; signingBytes != want {
+		t.Fatalf("signing bytes changed\n got: %s\nwant: %s", signingBytes, want)
+	}
+
+	data, _ := hex.DecodeString(goldenTransactionHex)
+	decoded, err := UnmarshalCanonicalTransaction(data)
+	if err != nil {
+		t.Fatalf("decoding the golden transaction failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, transaction) {
+		t.Fatalf("round trip changed the transaction\n got: %+v\nwant: %+v", decoded, transaction)
+	}
+}
+
+func TestTransactionCanonicalEncodingRoundTripsEveryKind(t *testing.T) {
+	for _, kind := range []TransactionKind{TransactionKindTransfer, TransactionKindCoinbase, TransactionKindAllocation, 255} {
+		transaction := goldenTransaction()
+		transaction.Kind = kind
+		transaction.PublicKey = nil
+		transaction.Signature = nil
+
+		decoded, err := UnmarshalCanonicalTransaction(transaction.MarshalCanonical())
+		if err != nil {
+			t.Fatalf("kind %d: %v", kind, err)
+		}
+		if !reflect.DeepEqual(decoded, transaction) {
+			t.Fatalf("kind %d: round trip changed the transaction\n got: %+v\nwant: %+v", kind, decoded, transaction)
+		}
+	}
+}
+
+func TestUnmarshalCanonicalTransactionRejectsTruncatedInput(t *testing.T) {
+	data, _ := hex.DecodeString(goldenTransactionHex)
+
+	for length := 0; length < len(data); length++ {
+		if _, err := UnmarshalCanonicalTransaction(data[:length]); err == nil {
+			t.Fatalf("decoded a transaction truncated to %d of %d bytes", length, len(data))
+		}
+	}
+
+	if _, err := UnmarshalCanonicalTransaction(data[:len(data)-1]); !errors.Is(err, ErrCanonicalEncodingTruncated) {
+		t.Fatalf("expected ErrCanonicalEncodingTruncated, got %v", err)
+	}
+}
+
+func TestUnmarshalCanonicalTransactionRejectsMalformedInput(t *testing.T) {
+	golden, _ := hex.DecodeString(goldenTransactionHex)
+
+	tests := []struct {
+		name   string
+		mutate func(data []byte) []byte
+	}{
+		{"trailing byte", func(data []byte) []byte { return append(data, 0x00) }},
+		{"wrong version", func(data []byte) []byte { data[0] = CanonicalEncodingVersion - 1; return data }},
+		{"block tag", func(data []byte) []byte { data[1] = CanonicalBlockTag; return data }},
+		// Kind 256 would truncate to a transfer, the golden transaction must have exactly one encoding.
+		{"kind above 255", func(data []byte) []byte { data[4] = 0x01; return data }},
+		{"kind with high bits", func(data []byte) []byte { data[2] = 0x80; return data }},
+		{"length past the end", func(data []byte) []byte { data[9] = 0xff; return data }},
+	}
+
+	for _, test := range tests {
+		data := test.mutate(append([]byte{}, golden...))
+		if _, err := UnmarshalCanonicalTransaction(data); err == nil {
+			t.Errorf("%s: decoded %x", test.name, data)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package primitives
+
+import (
+	ecdsa "crypto/ecdsa"
+	hmac "crypto/hmac"
+	sha256 "crypto/sha256"
+	errors "errors"
+	big "math/big"
+)
+
+// signRFC6979 computes an ECDSA signature whose nonce k is derived from the private key and the hash with
+// HMAC-SHA256 as described in RFC 6979 section 3.2, so signing needs no randomness and the same key and hash
+// always give the same signature. S is returned as computed, before any normalization.
+func signRFC6979(signingKey *ecdsa.PrivateKey, hash []byte) (*big.Int, *big.Int, error) {
+	if signingKey == nil || signingKey.Curve == nil || signingKey.D == nil {
+		return nil, nil, errors.New("missing signing key")
+	}
+
+	params := signingKey.Curve.Params()
+	n := params.N
+	if signingKey.D.Sign() <= 0 || signingKey.D.Cmp(n) >= 0 {
+		return nil, nil, errors.New("signing key is out of range")
+	}
+
+	size := (n.BitLen() + 7) / 8
+	e := bitsToInt(hash, n.BitLen())
+	nextNonce := rfc6979Nonces(signingKey.D, e, n)
+
+	for {
+		nonce := nextNonce()
+		x, _ := signingKey.Curve.ScalarBaseMult(intToOctets(nonce, size))
+		r := new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		s := new(big.Int).Mul(r, signingKey.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(nonce, n))
+		s.Mod(s, n)
+
+		if s.Sign() != 0 {
+			return r, s, nil
+		}
+	}
+}
+
+// rfc6979Nonces seeds the HMAC_DRBG with the private key and the hash as integer e, and returns a function
+// drawing the candidate nonces in [1, n-1] one after the other (RFC 6979 section 3.2 steps b to h).
+func rfc6979Nonces(privateKey *big.Int, e *big.Int, n *big.Int) func() *big.Int {
+	size := (n.BitLen() + 7) / 8
+	hashOctets := intToOctets(new(big.Int).Mod(e, n), size)
+	keyOctets := intToOctets(privateKey, size)
+
+	v := make([]byte, sha256.Size)
+	for index := range v {
+		v[index] = 0x01
+	}
+	k := make([]byte, sha256.Size)
+
+	k = hmacSha256(k, v, []byte{0x00}, keyOctets, hashOctets)
+	v = hmacSha256(k, v)
+	k = hmacSha256(k, v, []byte{0x01}, keyOctets, hashOctets)
+	v = hmacSha256(k, v)
+
+	drawn := false
+	return func() *big.Int {
+		for {
+			// Every draw after the first one, valid or not, first updates the state.
+			if drawn {
+				k = hmacSha256(k, v, []byte{0x00})
+				v = hmacSha256(k, v)
+			}
+			drawn = true
+
+			candidate := []byte{}
+			for len(candidate) < size {
+				v = hmacSha256(k, v)
+				candidate = append(candidate, v...)
+			}
+
+			if nonce := bitsToInt(candidate, n.BitLen()); nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+				return nonce
+			}
+		}
+	}
+}
+
+// bitsToInt interprets the leftmost bitLength bits of data as a big endian integer (RFC 6979 section 2.3.2).
+func bitsToInt(data []byte, bitLength int) *big.Int {
+	value := new(big.Int).SetBytes(data)
+	if excess := len(data)*8 - bitLength; excess > 0 {
+		value.Rsh(value, uint(excess))
+	}
+	return value
+}
+
+// intToOctets encodes value as a big endian integer of exactly size bytes (RFC 6979 section 2.3.3).
+func intToOctets(value *big.Int, size int) []byte {
+	return value.FillBytes(make([]byte, size))
+}
+
+func hmacSha256(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, chunk := range data {
+		mac.Write(chunk)
+	}
+	return mac.Sum(nil)
+}
@@ -0,0 +1,138 @@
+package primitives
+
+import (
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	sha256 "crypto/sha256"
+	big "math/big"
+	testing "testing"
+)
+
+func hexInt(t *testing.T, value string) *big.Int {
+	t.Helper()
+
+	number, ok := new(big.Int).SetString(value, 16)
+	if !ok {
+		t.Fatalf("invalid hex number %q", value)
+	}
+	return number
+}
+
+// rfc6979Key is the P-256 key of RFC 6979 appendix A.2.5.
+func rfc6979Key(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key := &ecdsa.PrivateKey{D: hexInt(t, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")}
+	key.PublicKey.Curve = elliptic.P256()
+	key.PublicKey.X, key.PublicKey.Y = key.PublicKey.Curve.ScalarBaseMult(key.D.Bytes())
+
+	if key.PublicKey.X.Cmp(hexInt(t, "60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6")) != 0 ||
+		key.PublicKey.Y.Cmp(hexInt(t, "7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299")) != 0 {
+		t.Fatal("public key does not match the RFC")
+	}
+	return key
+}
+
+// The ECDSA P-256 SHA-256 vectors of RFC 6979 appendix A.2.5.
+var rfc6979Vectors = []struct {
+	message string
+	k       string
+	r       string
+	s       string
+}{
+	{
+		message: "sample",
+		k:       "A6E3C57DD01ABE90086538398355DD4C3B17AA873382B0F24D6129493D8AAD60",
+		r:       "EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+		s:       "F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8",
+	},
+	{
+		message: "test",
+		k:       "D16B6AE827F17175E040871A1C7EC3500192C4C92677336EC2537ACAEE0008E0",
+		r:       "F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+		s:       "019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083",
+	},
+}
+
+func TestSignRFC6979Vectors(t *testing.T) {
+	key := rfc6979Key(t)
+	n := key.Curve.Params().N
+
+	for _, vector := range rfc6979Vectors {
+		hash := sha256.Sum256([]byte(vector.message))
+
+		k := rfc6979Nonces(key.D, bitsToInt(hash[:], n.BitLen()), n)()
+		if k.Cmp(hexInt(t, vector.k)) != 0 {
+			t.Errorf("%s: k = %X, want %s", vector.message, k, vector.k)
+		}
+
+		r, s, err := signRFC6979(key, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Cmp(hexInt(t, vector.r)) != 0 {
+			t.Errorf("%s: r = %X, want %s", vector.message, r, vector.r)
+		}
+		if s.Cmp(hexInt(t, vector.s)) != 0 {
+			t.Errorf("%s: s = %X, want %s", vector.message, s, vector.s)
+		}
+	}
+}
+
+func TestSignHashNormalizesToLowS(t *testing.T) {
+	key := rfc6979Key(t)
+	halfN := new(big.Int).Rsh(key.Curve.Params().N, 1)
+
+	for _, vector := range rfc6979Vectors {
+		hash := sha256.Sum256([]byte(vector.message))
+		signature, err := SignHash(key, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := new(big.Int).SetBytes(signature[:SignatureLength/2])
+		s := new(big.Int).SetBytes(signature[SignatureLength/2:])
+		if s.Cmp(halfN) > 0 {
+			t.Errorf("%s: S is above n/2", vector.message)
+		}
+		if r.Cmp(hexInt(t, vector.r)) != 0 {
+			t.Errorf("%s: r changed by the normalization", vector.message)
+		}
+
+		// "sample" has a high S in the RFC, "test" a low one. Either way the signature is the RFC's up to S.
+		if rfcS := hexInt(t, vector.s); s.Cmp(rfcS) != 0 && new(big.Int).Sub(key.Curve.Params().N, s).Cmp(rfcS) != 0 {
+			t.Errorf("%s: S is neither the RFC's S nor n - S", vector.message)
+		}
+
+		if !VerifySignature(&key.PublicKey, hash[:], signature) {
+			t.Errorf("%s: the low S signature does not verify", vector.message)
+		}
+	}
+}
+
+func TestVerifySignatureRejectsHighS(t *testing.T) {
+	key := rfc6979Key(t)
+	n := key.Curve.Params().N
+
+	for _, vector := range rfc6979Vectors {
+		hash := sha256.Sum256([]byte(vector.message))
+		signature, err := SignHash(key, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// n - s is just as valid for plain ECDSA, which is what made signatures malleable.
+		s := new(big.Int).SetBytes(signature[SignatureLength/2:])
+		r := new(big.Int).SetBytes(signature[:SignatureLength/2])
+		highS := new(big.Int).Sub(n, s)
+		if !ecdsa.Verify(&key.PublicKey, hash[:], r, highS) {
+			t.Fatalf("%s: n - s is not a valid plain ECDSA signature", vector.message)
+		}
+
+		malleated := append([]byte{}, signature...)
+		highS.FillBytes(malleated[SignatureLength/2:])
+		if VerifySignature(&key.PublicKey, hash[:], malleated) {
+			t.Errorf("%s: the high S signature n - s verifies", vector.message)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package primitives
+
+import (
+	ecdsa "crypto/ecdsa"
+	errors "errors"
+	big "math/big"
+)
+
+// SignatureLength is the size of a raw r||s signature, both halves left padded to 32 bytes.
+const SignatureLength = 64
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SignHash signs the hash with a deterministic RFC 6979 nonce and returns the raw r||s signature. S is
+// normalized to the lower half of the curve order, the only form VerifySignature accepts, so a valid
+// signature cannot be turned into a second valid one by negating S.
+func SignHash(signingKey *ecdsa.PrivateKey, hash []byte) ([]byte, error) {
+	r, s, err := signRFC6979(signingKey, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if n := signingKey.Curve.Params().N; s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+	}
+
+	signature := make([]byte, SignatureLength)
+	r.FillBytes(signature[:SignatureLength/2])
+	s.FillBytes(signature[SignatureLength/2:])
+	return signature, nil
+}
+
+// VerifySignature checks a raw r||s signature produced by SignHash. Signatures with a high S are rejected.
+func VerifySignature(publicKey *ecdsa.PublicKey, hash []byte, signature []byte) bool {
+	if publicKey == nil || publicKey.Curve == nil || len(signature) != SignatureLength {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:SignatureLength/2])
+	s := new(big.Int).SetBytes(signature[SignatureLength/2:])
+	if s.Cmp(new(big.Int).Rsh(publicKey.Curve.Params().N, 1)) > 0 {
+		return false
+	}
+
+	return ecdsa.Verify(publicKey, hash, r, s)
+}
@@ -0,0 +1,74 @@
+package settings
+
+import (
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	os "os"
+	time "time"
+)
+
+type GenesisAllocation struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+}
+
+// GenesisSpec describes block 0. Every node on the same network must boot from an identical spec.
+// Difficulty is the number of leading zero hex digits of the initial target, which is also the easiest
+// target the chain ever retargets to. TargetBlockTime is in seconds. MiningReward is the initial block
+// reward, which halves every HalvingInterval blocks until MaxSupply coins exist. Both limits are optional.
+// CoinbaseMaturity is the number of blocks a coinbase reward has to wait before it can be spent.
+type GenesisSpec struct {
+	ChainId          string              `json:"chainId"`
+	TimeStamp        time.Time           `json:"timeStamp"`
+	Difficulty       int                 `json:"difficulty"`
+	TargetBlockTime  int64               `json:"targetBlockTime"`
+	RetargetWindow   int64               `json:"retargetWindow"`
+	MiningReward     string              `json:"miningReward"`
+	HalvingInterval  int64               `json:"halvingInterval"`
+	MaxSupply        string              `json:"maxSupply,omitempty"`
+	CoinbaseMaturity int64               `json:"coinbaseMaturity"`
+	Allocations      []GenesisAllocation `json:"allocations"`
+}
+
+func LoadGenesisSpec(path string) (*GenesisSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read genesis spec: %v", err)
+	}
+
+	spec := &GenesisSpec{}
+	if err := json.Unmarshal(content, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse genesis spec: %v", err)
+	}
+
+	if spec.ChainId == "" {
+		return nil, errors.New("genesis spec must include a chain id")
+	}
+
+	if spec.TimeStamp.IsZero() {
+		return nil, errors.New("genesis spec must include a time stamp")
+	}
+
+	if spec.Difficulty < 0 || spec.Difficulty > 63 {
+		return nil, errors.New("genesis difficulty must be between 0 and 63")
+	}
+
+	if spec.TargetBlockTime <= 0 {
+		return nil, errors.New("genesis spec must include a positive target block time")
+	}
+
+	if spec.RetargetWindow <= 0 {
+		return nil, errors.New("genesis spec must include a positive retarget window")
+	}
+
+	if spec.HalvingInterval < 0 {
+		return nil, errors.New("genesis halving interval cannot be negative")
+	}
+
+	if spec.CoinbaseMaturity < 0 {
+		return nil, errors.New("genesis coinbase maturity cannot be negative")
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,14 @@
+package settings
+
+import (
+	time "time"
+)
+
+// KeystoreOptions configure the encrypted keystore. ScryptN and ScryptP are the scrypt cost parameters of
+// newly encrypted keys, UnlockTimeout is how long an unlocked key stays usable unless a timeout is requested.
+type KeystoreOptions struct {
+	Directory     string        `json:"directory"`
+	ScryptN       int           `json:"scryptN"`
+	ScryptP       int           `json:"scryptP"`
+	UnlockTimeout time.Duration `json:"unlockTimeout"`
+}
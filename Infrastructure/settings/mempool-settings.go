@@ -0,0 +1,14 @@
+package settings
+
+import (
+	time "time"
+)
+
+// MempoolOptions limit what the mempool accepts. MinRelayFeePerKb is the lowest fee, in the smallest
+// amount unit per 1000 bytes of canonical encoding, a transaction has to pay to be accepted.
+type MempoolOptions struct {
+	MaxTransactions          int           `json:"maxTransactions"`
+	MaxTransactionsPerSender int           `json:"maxTransactionsPerSender"`
+	TransactionExpiry        time.Duration `json:"transactionExpiry"`
+	MinRelayFeePerKb         int64         `json:"minRelayFeePerKb"`
+}
@@ -0,0 +1,8 @@
+package settings
+
+// MiningOptions configure the node's miner. TemplateSize is the maximum number of mempool transactions mined
+// into one block, Workers the number of goroutines searching nonces, one per GOMAXPROCS when zero.
+type MiningOptions struct {
+	TemplateSize int `json:"templateSize"`
+	Workers      int `json:"workers"`
+}
@@ -0,0 +1,324 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	errors "errors"
+	fmt "fmt"
+	sort "sort"
+)
+
+var ErrInsufficientBalance = errors.New("sender balance does not cover the transaction amount and fee")
+
+// AccountState is the state of one address after the transactions of the chain were applied.
+type AccountState struct {
+	Address            string
+	Balance            primitives.Amount
+	Nonce              uint64
+	LastActivityHeight int64
+}
+
+// AccountStateStore persists account states together with the tip they were applied up to. Updated states
+// are upserted, removed addresses are accounts that no longer exist after a block was rolled back. The tip
+// has to be written after the states, so that a stored tip means every state up to it was written.
+type AccountStateStore interface {
+	LoadAccountStates() ([]AccountState, AppliedTip, error)
+	SaveAccountStates(updated []AccountState, removed []string, tip AppliedTip) error
+}
+
+// AppliedTip is the block the stored account states were last brought up to. It is the zero value when
+// no tip was stored yet.
+type AppliedTip struct {
+	Height int64
+	Hash   string
+}
+
+// BlockUndo is what reverting a block needs besides the block itself, the LastActivityHeight every address
+// it touched had before it. Addresses the block created have -1.
+type BlockUndo []AccountUndo
+
+type AccountUndo struct {
+	Address                    string
+	PreviousLastActivityHeight int64
+}
+
+// AccountStateDiff is one address on which two sets of account states disagree.
+type AccountStateDiff struct {
+	Address  string
+	Expected *AccountState
+	Actual   *AccountState
+}
+
+// AccountStates is the incrementally maintained account state database of the chain.
+// Applying a block enforces the nonce and balance rules on every transaction in it.
+type AccountStates struct {
+	accounts map[string]AccountState
+}
+
+func NewAccountStates(states []AccountState) *AccountStates {
+	accountStates := &AccountStates{
+		accounts: make(map[string]AccountState, len(states)),
+	}
+
+	for _, state := range states {
+		accountStates.accounts[state.Address] = state
+	}
+
+	return accountStates
+}
+
+// RebuildAccountStates replays the whole chain from genesis.
+func RebuildAccountStates(chain []Block, coinbaseMaturity int64) (*AccountStates, error) {
+	accountStates := NewAccountStates(nil)
+
+	for height := range chain {
+		immature := ImmatureCoinbaseRewards(chain, &chain[height], int64(height), coinbaseMaturity)
+		if _, err := accountStates.ApplyBlock(&chain[height], int64(height), immature); err != nil {
+			return nil, fmt.Errorf("block %d: %w", height, err)
+		}
+	}
+
+	return accountStates, nil
+}
+
+// Get returns the state of the address. Unknown addresses have an empty state.
+func (accountStates *AccountStates) Get(address string) AccountState {
+	state, ok := accountStates.accounts[address]
+	if !ok {
+		return AccountState{Address: address, LastActivityHeight: -1}
+	}
+	return state
+}
+
+func (accountStates *AccountStates) Exists(address string) bool {
+	_, ok := accountStates.accounts[address]
+	return ok
+}
+
+// All returns every account state ordered by address.
+func (accountStates *AccountStates) All() []AccountState {
+	states := make([]AccountState, 0, len(accountStates.accounts))
+	for _, state := range accountStates.accounts {
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Address < states[j].Address
+	})
+
+	return states
+}
+
+// Addresses returns the addresses the block touched, in the order it touched them.
+func (undo BlockUndo) Addresses() []string {
+	addresses := make([]string, 0, len(undo))
+	for _, account := range undo {
+		addresses = append(addresses, account.Address)
+	}
+	return addresses
+}
+
+// NewBlockUndos derives the undo records of a chain from its blocks alone, e.g. for account states that
+// were loaded from a store instead of being applied.
+func NewBlockUndos(chain []Block) []BlockUndo {
+	lastActivityHeights := map[string]int64{}
+	undos := make([]BlockUndo, 0, len(chain))
+
+	for height := range chain {
+		undo := BlockUndo{}
+		seen := map[string]bool{}
+		remember := func(address string) {
+			if seen[address] {
+				return
+			}
+			seen[address] = true
+
+			previous, ok := lastActivityHeights[address]
+			if !ok {
+				previous = -1
+			}
+			undo = append(undo, AccountUndo{Address: address, PreviousLastActivityHeight: previous})
+		}
+
+		for index := range chain[height].Transactions {
+			transaction := &chain[height].Transactions[index]
+			if transaction.Kind == primitives.TransactionKindTransfer {
+				remember(transaction.FromAddress)
+			}
+			remember(transaction.ToAddress)
+		}
+
+		for _, account := range undo {
+			lastActivityHeights[account.Address] = int64(height)
+		}
+		undos = append(undos, undo)
+	}
+
+	return undos
+}
+
+// ApplyBlock applies the transactions of the block at the given height and returns its undo record.
+// A sender can never spend below the immature coinbase rewards it holds, see ImmatureCoinbaseRewards.
+// When a transaction is rejected the states are left exactly as they were before the call.
+func (accountStates *AccountStates) ApplyBlock(block *Block, height int64, immature func(address string) primitives.Amount) (BlockUndo, error) {
+	previousStates := make(map[string]*AccountState)
+	undo := BlockUndo{}
+
+	remember := func(address string) {
+		if _, ok := previousStates[address]; ok {
+			return
+		}
+
+		if state, ok := accountStates.accounts[address]; ok {
+			previousStates[address] = &state
+			undo = append(undo, AccountUndo{Address: address, PreviousLastActivityHeight: state.LastActivityHeight})
+		} else {
+			previousStates[address] = nil
+			undo = append(undo, AccountUndo{Address: address, PreviousLastActivityHeight: -1})
+		}
+	}
+
+	for index := range block.Transactions {
+		transaction := &block.Transactions[index]
+		if transaction.Kind == primitives.TransactionKindTransfer {
+			remember(transaction.FromAddress)
+		}
+		remember(transaction.ToAddress)
+
+		if err := accountStates.applyTransaction(transaction, height, immature); err != nil {
+			for address, state := range previousStates {
+				if state == nil {
+					delete(accountStates.accounts, address)
+				} else {
+					accountStates.accounts[address] = *state
+				}
+			}
+			return nil, err
+		}
+	}
+
+	return undo, nil
+}
+
+// RevertBlock undoes ApplyBlock for the block at the top of the applied chain, given the undo record ApplyBlock
+// returned for it. Accounts without any earlier activity are removed.
+func (accountStates *AccountStates) RevertBlock(block *Block, undo BlockUndo) (updated []string, removed []string, err error) {
+	for index := len(block.Transactions) - 1; index >= 0; index-- {
+		transaction := &block.Transactions[index]
+
+		recipient := accountStates.Get(transaction.ToAddress)
+		if recipient.Balance, err = recipient.Balance.Sub(transaction.Amount); err != nil {
+			return nil, nil, err
+		}
+		accountStates.accounts[transaction.ToAddress] = recipient
+
+		if transaction.Kind != primitives.TransactionKindTransfer {
+			continue
+		}
+
+		cost, err := transaction.TotalCost()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sender := accountStates.Get(transaction.FromAddress)
+		if sender.Balance, err = sender.Balance.Add(cost); err != nil {
+			return nil, nil, err
+		}
+		sender.Nonce--
+		accountStates.accounts[transaction.FromAddress] = sender
+	}
+
+	for _, account := range undo {
+		if account.PreviousLastActivityHeight < 0 {
+			delete(accountStates.accounts, account.Address)
+			removed = append(removed, account.Address)
+			continue
+		}
+
+		state := accountStates.accounts[account.Address]
+		state.LastActivityHeight = account.PreviousLastActivityHeight
+		accountStates.accounts[account.Address] = state
+		updated = append(updated, account.Address)
+	}
+
+	return updated, removed, nil
+}
+
+// DiffAccountStates compares two sets of account states and returns every address on which they disagree.
+func DiffAccountStates(expected []AccountState, actual []AccountState) []AccountStateDiff {
+	actualByAddress := make(map[string]AccountState, len(actual))
+	for _, state := range actual {
+		actualByAddress[state.Address] = state
+	}
+
+	diffs := []AccountStateDiff{}
+	for index := range expected {
+		expectedState := expected[index]
+		actualState, ok := actualByAddress[expectedState.Address]
+		delete(actualByAddress, expectedState.Address)
+
+		if !ok {
+			diffs = append(diffs, AccountStateDiff{Address: expectedState.Address, Expected: &expectedState})
+		} else if actualState != expectedState {
+			diffs = append(diffs, AccountStateDiff{Address: expectedState.Address, Expected: &expectedState, Actual: &actualState})
+		}
+	}
+
+	for address := range actualByAddress {
+		actualState := actualByAddress[address]
+		diffs = append(diffs, AccountStateDiff{Address: address, Actual: &actualState})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Address < diffs[j].Address
+	})
+
+	return diffs
+}
+
+func (accountStates *AccountStates) applyTransaction(transaction *primitives.BlockTransaction, height int64, immature func(address string) primitives.Amount) error {
+	if transaction.Amount.IsNegative() || transaction.Fee.IsNegative() {
+		return fmt.Errorf("%w: negative amount %s or fee %s", primitives.ErrInvalidAmount, transaction.Amount, transaction.Fee)
+	}
+
+	if transaction.Kind == primitives.TransactionKindTransfer {
+		sender := accountStates.Get(transaction.FromAddress)
+		if transaction.Nonce != sender.Nonce {
+			return fmt.Errorf("%w: expected %d, got %d", ErrInvalidNonce, sender.Nonce, transaction.Nonce)
+		}
+
+		cost, err := transaction.TotalCost()
+		if err != nil {
+			return err
+		}
+
+		balance, err := sender.Balance.Sub(cost)
+		if err != nil {
+			return err
+		}
+
+		if balance.IsNegative() {
+			return fmt.Errorf("%w: %s spends %s", ErrInsufficientBalance, transaction.FromAddress, cost)
+		}
+
+		if immature != nil && balance < immature(transaction.FromAddress) {
+			return fmt.Errorf("%w: %s spends %s of which %s is immature", ErrImmatureCoinbase, transaction.FromAddress, cost, immature(transaction.FromAddress))
+		}
+
+		sender.Balance = balance
+		sender.Nonce++
+		sender.LastActivityHeight = height
+		accountStates.accounts[transaction.FromAddress] = sender
+	}
+
+	recipient := accountStates.Get(transaction.ToAddress)
+	balance, err := recipient.Balance.Add(transaction.Amount)
+	if err != nil {
+		return err
+	}
+
+	recipient.Balance = balance
+	recipient.LastActivityHeight = height
+	accountStates.accounts[transaction.ToAddress] = recipient
+	return nil
+}
@@ -1,80 +1,379 @@
 package utilities
 
 import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	context "context"
 	ecdsa "crypto/ecdsa"
-	elliptic "crypto/elliptic"
-	rand "crypto/rand"
+	errors "errors"
 	fmt "fmt"
+	sync "sync"
 	time "time"
+)
 
-	decimal "github.com/shopspring/decimal"
+var (
+	ErrInvalidNonce = errors.New("transaction nonce is not the next expected nonce of the sender")
+	ErrStaleBlock   = errors.New("the chain tip changed while the block was mined")
 )
 
+// BlockStore persists the canonical chain so that it survives restarts. A block's height is its
+// position in Blockchain.Chain. RemoveBlocksAbove is used by reorganizations to drop the old branch.
+type BlockStore interface {
+	LoadBlocks() ([]Block, error)
+	AppendBlock(block Block) error
+	RemoveBlocksAbove(height int64) error
+}
+
+// Blockchain is the canonical chain with its account states. Pending transactions are kept by the
+// mempool, the chain only validates blocks. All methods are safe for concurrent use, subscribers are
+// called after the chain was unlocked.
 type Blockchain struct {
-	Chain               []Block
-	Difficulty          int
-	PendingTransactions []BlockTransaction
-	MiningReward        decimal.Decimal
+	ChainId          string
+	Chain            []Block
+	Difficulty       DifficultyParams
+	Emission         EmissionSchedule
+	CoinbaseMaturity int64
+	mutex            sync.RWMutex
+	accounts         *AccountStates
+	undos            []BlockUndo
+	tree             *BlockTree
+	blockSubscribers []func(block Block)
+	reorgSubscribers []func(event ReorgEvent)
+	store            BlockStore
+	accountStore     AccountStateStore
+	// unsavedAccounts are the addresses of a failed account state save, they are saved with the next one.
+	unsavedAccounts []string
 }
 
-func NewBlockchain() *Blockchain {
+func NewBlockchain(genesisSpec *settings.GenesisSpec) (*Blockchain, error) {
+	genesisBlock, err := createGenesisBlock(genesisSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	emission, err := NewEmissionSchedule(genesisSpec, &genesisBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := RebuildAccountStates([]Block{genesisBlock}, genesisSpec.CoinbaseMaturity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis allocations: %v", err)
+	}
+
 	blockchain := &Blockchain{
-		Chain:               createGenesisBlock(),
-		Difficulty:          2,
-		PendingTransactions: make([]BlockTransaction, 0),
-		MiningReward:        decimal.NewFromFloat(100),
+		ChainId:          genesisSpec.ChainId,
+		Chain:            []Block{genesisBlock},
+		Difficulty:       NewDifficultyParams(genesisSpec),
+		Emission:         emission,
+		CoinbaseMaturity: genesisSpec.CoinbaseMaturity,
+		accounts:         accounts,
+		undos:            NewBlockUndos([]Block{genesisBlock}),
+		tree:             NewBlockTree(genesisBlock),
+	}
+	return blockchain, nil
+}
+
+// NewBlockchainFromStore loads the chain and the account states from the stores. An empty store is seeded
+// with the genesis block, otherwise the stored genesis block has to match the one derived from the spec.
+// Stored hashes are not trusted, every block has to hash to the hash it was stored under.
+// Stored account states are only trusted when they were applied up to the stored tip, otherwise they are
+// rebuilt from the chain.
+func NewBlockchainFromStore(genesisSpec *settings.GenesisSpec, store BlockStore, accountStore AccountStateStore) (*Blockchain, error) {
+	blockchain, err := NewBlockchain(genesisSpec)
+	if err != nil {
+		return nil, err
+	}
+	blockchain.store = store
+	blockchain.accountStore = accountStore
+
+	blocks, err := store.LoadBlocks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load blocks: %v", err)
+	}
+
+	if len(blocks) == 0 {
+		if err := store.AppendBlock(blockchain.Chain[0]); err != nil {
+			return nil, fmt.Errorf("failed to store genesis block: %v", err)
+		}
+
+		if err := accountStore.SaveAccountStates(blockchain.accounts.All(), nil, blockchain.appliedTip()); err != nil {
+			return nil, fmt.Errorf("failed to store genesis account states: %v", err)
+		}
+		return blockchain, nil
+	}
+
+	for index := range blocks {
+		if hash := blocks[index].CalculateHash(); blocks[index].Hash != hash {
+			return nil, fmt.Errorf("%w: stored block %d hashes to %s, not %s", ErrInvalidBlockHeader, index, hash, blocks[index].Hash)
+		}
+	}
+
+	genesisHash := blockchain.Chain[0].Hash
+	if blocks[0].Hash != genesisHash {
+		return nil, fmt.Errorf("stored genesis block %s does not match genesis %s of chain %s", blocks[0].Hash, genesisHash, blockchain.ChainId)
+	}
+
+	for index := 1; index < len(blocks); index++ {
+		if blocks[index].Header.PreviousHash != blocks[index-1].Hash || blocks[index].Header.Height != int64(index) {
+			return nil, errors.New("stored blocks do not form a chain")
+		}
+
+		if _, err := blockchain.tree.Add(blocks[index]); err != nil {
+			return nil, err
+		}
 	}
-	return blockchain
+
+	blockchain.Chain = blocks
+	blockchain.undos = NewBlockUndos(blocks)
+
+	states, appliedTip, err := accountStore.LoadAccountStates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load account states: %v", err)
+	}
+
+	if appliedTip == blockchain.appliedTip() {
+		blockchain.accounts = NewAccountStates(states)
+		return blockchain, nil
+	}
+
+	// The states are missing or were interrupted on the way to the stored tip. Replaying from the applied tip
+	// is not an option, the states of the interrupted write may already be stored past it.
+	if blockchain.accounts, err = RebuildAccountStates(blocks, blockchain.CoinbaseMaturity); err != nil {
+		return nil, fmt.Errorf("failed to rebuild account states: %v", err)
+	}
+
+	removed := []string{}
+	for _, state := range states {
+		if !blockchain.accounts.Exists(state.Address) {
+			removed = append(removed, state.Address)
+		}
+	}
+
+	if err := accountStore.SaveAccountStates(blockchain.accounts.All(), removed, blockchain.appliedTip()); err != nil {
+		return nil, fmt.Errorf("failed to store account states: %v", err)
+	}
+
+	return blockchain, nil
+}
+
+// NewBlockTemplate builds an unmined block with the given transactions, e.g. a mempool template, on top of the
+// current tip. The block starts with the reward transaction paying the miner the reward plus the fees of the transactions.
+// Its time stamp is the current time, or the parent's when the local clock is behind it.
+func (blockChain *Blockchain) NewBlockTemplate(transactions []primitives.BlockTransaction, miningRewardAddress string) (*Block, error) {
+	blockChain.mutex.RLock()
+	tip := blockChain.tipNode()
+	bits := blockChain.nextBlockBits(tip)
+	blockChain.mutex.RUnlock()
+
+	height := tip.Height() + 1
+	coinbase, err := NewCoinbaseTransaction(height, miningRewardAddress, blockChain.Emission.BlockReward(height), transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	timeStamp := time.Now()
+	if timeStamp.Before(tip.Block.Header.TimeStamp) {
+		timeStamp = tip.Block.Header.TimeStamp
+	}
+
+	blockTransactions := append([]primitives.BlockTransaction{coinbase}, transactions...)
+	return NewBlock(height, timeStamp, blockTransactions, tip.Block.Hash, miningRewardAddress, bits), nil
 }
 
-// MinePendingTransactions mines pending transactions and adds a new block to the blockchain.
-func (blockChain *Blockchain) MinePendingTransactions(miningRewardAddress string, signingKey *ecdsa.PrivateKey) {
-	block := NewBlock(time.Now(), blockChain.PendingTransactions, getLatestBlockHash(blockChain.Chain), miningRewardAddress, signingKey)
-	block.MineBlock(blockChain.Difficulty)
-	blockChain.Chain = append(blockChain.Chain, *block)
+// SubmitBlock connects a block mined from one of the node's own templates. The chain is not locked while
+// mining, so the block is rejected with ErrStaleBlock when the tip moved meanwhile.
+func (blockChain *Blockchain) SubmitBlock(block *Block) error {
+	blockChain.mutex.Lock()
+	if getLatestBlockHash(blockChain.Chain) != block.Header.PreviousHash {
+		blockChain.mutex.Unlock()
+		return ErrStaleBlock
+	}
+
+	if _, err := blockChain.tree.Add(*block); err != nil {
+		blockChain.mutex.Unlock()
+		return err
+	}
 
-	// Reset pending transactions and create a new transaction to send the miner a reward
-	blockChain.PendingTransactions = []BlockTransaction{
-		{ToAddress: miningRewardAddress, Amount: blockChain.MiningReward},
+	if err := blockChain.connectBlock(block); err != nil {
+		blockChain.tree.Remove(block.Hash)
+		blockChain.mutex.Unlock()
+		return err
 	}
+	blockChain.mutex.Unlock()
+
+	blockChain.notify(block, nil)
+	return nil
 }
 
-func (blockChain *Blockchain) AddTransaction(transaction BlockTransaction) {
-	if transaction.FromAddress == "" || transaction.ToAddress == "" {
-		panic("Transaction must include from and to address.")
+// MineTransactions mines a block with the given transactions on top of the current tip and connects it.
+// Mining uses one worker per GOMAXPROCS and stops with the context's error when ctx is cancelled.
+func (blockChain *Blockchain) MineTransactions(ctx context.Context, transactions []primitives.BlockTransaction, miningRewardAddress string, signingKey *ecdsa.PrivateKey) error {
+	block, err := blockChain.NewBlockTemplate(transactions, miningRewardAddress)
+	if err != nil {
+		return err
 	}
 
-	if !transaction.IsValid() {
-		panic("Cannot add invalid transaction to the chain.")
+	if err := block.MineBlock(ctx, signingKey, 0, nil); err != nil {
+		return err
 	}
 
-	blockChain.PendingTransactions = append(blockChain.PendingTransactions, transaction)
+	return blockChain.SubmitBlock(block)
+}
+
+// SubscribeBlocks registers a handler that is called whenever a block extends the canonical chain.
+func (blockChain *Blockchain) SubscribeBlocks(handler func(block Block)) {
+	blockChain.mutex.Lock()
+	defer blockChain.mutex.Unlock()
+
+	blockChain.blockSubscribers = append(blockChain.blockSubscribers, handler)
+}
+
+// Blocks returns a snapshot of the canonical chain.
+func (blockChain *Blockchain) Blocks() []Block {
+	blockChain.mutex.RLock()
+	defer blockChain.mutex.RUnlock()
+
+	return append([]Block{}, blockChain.Chain...)
+}
+
+func (blockChain *Blockchain) GetBalanceOfAddress(address string) primitives.Amount {
+	return blockChain.GetAccountState(address).Balance
 }
 
-func (blockChain *Blockchain) GetBalanceOfAddress(address string) decimal.Decimal {
-	balance := decimal.NewFromFloat(0)
+// GetMatureBalance is the part of the balance the address can spend in the next block, i.e. without
+// the coinbase rewards that did not reach their maturity yet.
+func (blockChain *Blockchain) GetMatureBalance(address string) primitives.Amount {
+	blockChain.mutex.RLock()
+	defer blockChain.mutex.RUnlock()
+
+	height := int64(len(blockChain.Chain))
+	immature := ImmatureCoinbaseRewards(blockChain.Chain, nil, height, blockChain.CoinbaseMaturity)
+	return blockChain.accounts.Get(address).Balance - immature(address)
+}
 
-	for _, block := range blockChain.Chain {
-		for _, transaction := range block.Transactions {
-			if transaction.FromAddress == address {
-				balance = balance.Sub(transaction.Amount)
+// GetSupply adds up what the genesis allocations and the coinbase transactions of the chain created,
+// the fees a coinbase collects are not new coins.
+func (blockChain *Blockchain) GetSupply() (SupplyInfo, error) {
+	blockChain.mutex.RLock()
+	defer blockChain.mutex.RUnlock()
+
+	var issued primitives.Amount
+	var err error
+	for height := range blockChain.Chain {
+		for index := range blockChain.Chain[height].Transactions {
+			transaction := &blockChain.Chain[height].Transactions[index]
+			switch transaction.Kind {
+			case primitives.TransactionKindCoinbase, primitives.TransactionKindAllocation:
+				issued, err = issued.Add(transaction.Amount)
+			default:
+				issued, err = issued.Sub(transaction.Fee)
 			}
 
-			if transaction.ToAddress == address {
-				balance = balance.Add(transaction.Amount)
+			if err != nil {
+				return SupplyInfo{}, err
 			}
 		}
 	}
 
-	return balance
+	height := int64(len(blockChain.Chain) - 1)
+	return SupplyInfo{
+		Height:            height,
+		IssuedSupply:      issued,
+		MaxSupply:         blockChain.Emission.MaxSupply,
+		CurrentReward:     blockChain.Emission.BlockReward(height + 1),
+		NextHalvingHeight: blockChain.Emission.NextHalvingHeight(height + 1),
+	}, nil
+}
+
+// FindTransaction looks up a transaction by id and returns the block that contains it and its height.
+func (blockChain *Blockchain) FindTransaction(transactionId string) (*Block, int64, bool) {
+	blockChain.mutex.RLock()
+	defer blockChain.mutex.RUnlock()
+
+	for height := len(blockChain.Chain) - 1; height >= 0; height-- {
+		block := blockChain.Chain[height]
+		for index := range block.Transactions {
+			if block.Transactions[index].TransactionId() == transactionId {
+				return &block, int64(height), true
+			}
+		}
+	}
+
+	return nil, 0, false
+}
+
+func (blockChain *Blockchain) GetAccountState(address string) AccountState {
+	blockChain.mutex.RLock()
+	defer blockChain.mutex.RUnlock()
+
+	return blockChain.accounts.Get(address)
+}
+
+// RepairAccountStates rebuilds the account states from the chain and diffs them against the stored ones, or against
+// the in memory ones without a store. With repair set and a difference found, the rebuilt states replace the in
+// memory and the stored ones. The chain stays locked throughout, so the rebuilt states are saved under the tip
+// they were rebuilt at.
+func (blockChain *Blockchain) RepairAccountStates(repair bool) ([]AccountStateDiff, error) {
+	blockChain.mutex.Lock()
+	defer blockChain.mutex.Unlock()
+
+	rebuilt, err := RebuildAccountStates(blockChain.Chain, blockChain.CoinbaseMaturity)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := blockChain.accounts.All()
+	if blockChain.accountStore != nil {
+		if stored, _, err = blockChain.accountStore.LoadAccountStates(); err != nil {
+			return nil, err
+		}
+	}
+
+	diffs := DiffAccountStates(rebuilt.All(), stored)
+	if !repair || len(diffs) == 0 {
+		return diffs, nil
+	}
+
+	removed := []string{}
+	for _, state := range stored {
+		if !rebuilt.Exists(state.Address) {
+			removed = append(removed, state.Address)
+		}
+	}
+
+	blockChain.accounts = rebuilt
+	if blockChain.accountStore == nil {
+		return diffs, nil
+	}
+
+	if err := blockChain.accountStore.SaveAccountStates(rebuilt.All(), removed, blockChain.appliedTip()); err != nil {
+		return nil, err
+	}
+
+	blockChain.unsavedAccounts = nil
+	return diffs, nil
 }
 
 func (blockChain *Blockchain) IsChainValid() bool {
+	blockChain.mutex.RLock()
+	defer blockChain.mutex.RUnlock()
+
+	accounts := NewAccountStates(nil)
+	if _, err := accounts.ApplyBlock(&blockChain.Chain[0], 0, nil); err != nil {
+		return false
+	}
+
 	for index := 1; index < len(blockChain.Chain); index++ {
 		currentBlock := &blockChain.Chain[index]
 		previousBlock := &blockChain.Chain[index-1]
 
-		if !currentBlock.IsBlockSignatureValid() {
+		if err := ValidateBlockHeader(&currentBlock.Header, &previousBlock.Header, time.Now()); err != nil {
+			return false
+		}
+
+		parent, ok := blockChain.tree.Get(previousBlock.Hash)
+		if !ok || currentBlock.Header.Bits != blockChain.nextBlockBits(parent) {
 			return false
 		}
 
@@ -82,11 +381,20 @@ func (blockChain *Blockchain) IsChainValid() bool {
 			return false
 		}
 
+		if !currentBlock.HasValidMerkleRoot() {
+			return false
+		}
+
+		if err := ValidateCoinbase(currentBlock, blockChain.Emission.BlockReward(int64(index))); err != nil {
+			return false
+		}
+
 		if currentBlock.Hash != currentBlock.CalculateHash() {
 			return false
 		}
 
-		if currentBlock.PreviousHash != previousBlock.Hash {
+		immature := ImmatureCoinbaseRewards(blockChain.Chain, currentBlock, int64(index), blockChain.CoinbaseMaturity)
+		if _, err := accounts.ApplyBlock(currentBlock, int64(index), immature); err != nil {
 			return false
 		}
 	}
@@ -94,26 +402,75 @@ func (blockChain *Blockchain) IsChainValid() bool {
 	return true
 }
 
-func createGenesisBlock() []Block {
-	return []Block{
-		{
-			TimeStamp:    time.Now(),
-			Transactions: []BlockTransaction{},
-			PreviousHash: "",
-			BlockMiner:   "",
-			SigningKey:   createSigningKey(),
-		},
+// connectBlock applies a block on top of the canonical chain and stores it.
+func (blockChain *Blockchain) connectBlock(block *Block) error {
+	touched, err := blockChain.connectTip(block)
+	if err != nil {
+		return err
+	}
+
+	if blockChain.store != nil {
+		if err := blockChain.store.AppendBlock(*block); err != nil {
+			blockChain.disconnectTip()
+			return err
+		}
 	}
+
+	// The block is already stored, a failure here leaves the applied tip of the stored account states behind
+	// the chain, so they are rebuilt on the next start.
+	return blockChain.saveAccountStates(touched)
 }
 
-func getLatestBlockHash(chain []Block) string {
-	return chain[len(chain)-1].Hash
+// nextBlockBits returns the target the difficulty rules prescribe for a block on top of parent, on whatever branch it is.
+func (blockChain *Blockchain) nextBlockBits(parent *BlockTreeNode) uint32 {
+	var windowStart *BlockHeader
+	if ancestor := parent.Ancestor(parent.Height() - blockChain.Difficulty.RetargetWindow); ancestor != nil {
+		windowStart = &ancestor.Block.Header
+	}
+
+	return blockChain.Difficulty.NextBlockBits(&parent.Block.Header, windowStart)
 }
 
-func createSigningKey() *ecdsa.PrivateKey {
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		panic(fmt.Errorf("failed to generate key: %v", err))
+// appliedTip is the tip the account states are applied up to, which is always the tip of the canonical chain.
+func (blockChain *Blockchain) appliedTip() AppliedTip {
+	return AppliedTip{Height: int64(len(blockChain.Chain) - 1), Hash: getLatestBlockHash(blockChain.Chain)}
+}
+
+// saveAccountStates stores the current state of every touched address, or removes it when the address no longer
+// exists, and marks the states as applied up to the current tip. The addresses of a failed save are kept for
+// the next one, otherwise its tip would cover states that were never written.
+func (blockChain *Blockchain) saveAccountStates(touched []string) error {
+	if blockChain.accountStore == nil {
+		return nil
 	}
-	return key
+
+	seen := map[string]bool{}
+	addresses := []string{}
+	states := []AccountState{}
+	removed := []string{}
+	for _, address := range append(blockChain.unsavedAccounts, touched...) {
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+
+		if blockChain.accounts.Exists(address) {
+			states = append(states, blockChain.accounts.Get(address))
+		} else {
+			removed = append(removed, address)
+		}
+	}
+
+	if err := blockChain.accountStore.SaveAccountStates(states, removed, blockChain.appliedTip()); err != nil {
+		blockChain.unsavedAccounts = addresses
+		return err
+	}
+
+	blockChain.unsavedAccounts = nil
+	return nil
+}
+
+func getLatestBlockHash(chain []Block) string {
+	return chain[len(chain)-1].Hash
 }
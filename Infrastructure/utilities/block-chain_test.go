@@ -0,0 +1,414 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	errors "errors"
+	reflect "reflect"
+	testing "testing"
+	time "time"
+)
+
+var errStoreUnavailable = errors.New("store unavailable")
+
+// memoryStore keeps the chain and the account states the way the Mongo stores do. The next failAppends
+// appends fail.
+type memoryStore struct {
+	blocks      []Block
+	states      map[string]AccountState
+	appliedTip  AppliedTip
+	failAppends int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{states: map[string]AccountState{}}
+}
+
+func (store *memoryStore) LoadBlocks() ([]Block, error) {
+	return append([]Block{}, store.blocks...), nil
+}
+
+func (store *memoryStore) AppendBlock(block Block) error {
+	if store.failAppends > 0 {
+		store.failAppends--
+		return errStoreUnavailable
+	}
+	store.blocks = append(store.blocks, block)
+	return nil
+}
+
+func (store *memoryStore) RemoveBlocksAbove(height int64) error {
+	store.blocks = store.blocks[:height+1]
+	return nil
+}
+
+func (store *memoryStore) LoadAccountStates() ([]AccountState, AppliedTip, error) {
+	return NewAccountStates(store.stateList()).All(), store.appliedTip, nil
+}
+
+func (store *memoryStore) SaveAccountStates(updated []AccountState, removed []string, tip AppliedTip) error {
+	for _, state := range updated {
+		store.states[state.Address] = state
+	}
+	for _, address := range removed {
+		delete(store.states, address)
+	}
+	store.appliedTip = tip
+	return nil
+}
+
+func (store *memoryStore) stateList() []AccountState {
+	states := []AccountState{}
+	for _, state := range store.states {
+		states = append(states, state)
+	}
+	return states
+}
+
+type testAccount struct {
+	key     *ecdsa.PrivateKey
+	address string
+}
+
+func newTestAccount(t *testing.T) testAccount {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testAccount{key: key, address: primitives.PublicKeyToAddress(&key.PublicKey)}
+}
+
+// newTestGenesisSpec is a chain without proof of work and with immediately spendable rewards that allocates
+// 100 coins to funded.
+func newTestGenesisSpec(funded testAccount) *settings.GenesisSpec {
+	return &settings.GenesisSpec{
+		ChainId:         "test",
+		TimeStamp:       time.Now().Add(-time.Hour).UTC(),
+		TargetBlockTime: 60,
+		RetargetWindow:  10,
+		MiningReward:    "50",
+		Allocations:     []settings.GenesisAllocation{{Address: funded.address, Amount: "100"}},
+	}
+}
+
+// transfer signs a transfer of coins from one test account to an address.
+func transfer(t *testing.T, from testAccount, to string, coins int64, nonce uint64) primitives.BlockTransaction {
+	t.Helper()
+
+	transaction := primitives.NewBlockTransaction(from.address, to, primitives.Amount(coins)*primitives.AmountUnitsPerCoin, 1000, nonce)
+	if err := transaction.SignTransaction(from.key); err != nil {
+		t.Fatal(err)
+	}
+	return *transaction
+}
+
+// mineBlock mines the transactions on top of the current tip without connecting the block.
+func mineBlock(t *testing.T, blockchain *Blockchain, miner testAccount, transactions ...primitives.BlockTransaction) *Block {
+	t.Helper()
+
+	block, err := blockchain.NewBlockTemplate(transactions, miner.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := block.MineBlock(context.Background(), miner.key, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+// mineOn mines an empty block on top of parent, which does not have to be the tip.
+func mineOn(t *testing.T, blockchain *Blockchain, parent *Block, miner testAccount) Block {
+	t.Helper()
+
+	return mineAt(t, blockchain, parent, miner, parent.Header.TimeStamp.Add(time.Second))
+}
+
+func mineAt(t *testing.T, blockchain *Blockchain, parent *Block, miner testAccount, timeStamp time.Time) Block {
+	t.Helper()
+
+	parentNode, ok := blockchain.tree.Get(parent.Hash)
+	if !ok {
+		t.Fatal("parent is not in the tree")
+	}
+
+	height := parent.Header.Height + 1
+	coinbase, err := NewCoinbaseTransaction(height, miner.address, blockchain.Emission.BlockReward(height), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := NewBlock(height, timeStamp, []primitives.BlockTransaction{coinbase}, parent.Hash, miner.address, blockchain.nextBlockBits(parentNode))
+	if err := block.MineBlock(context.Background(), miner.key, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	return *block
+}
+
+func storedHashes(store *memoryStore) []string {
+	hashes := []string{}
+	for _, block := range store.blocks {
+		hashes = append(hashes, block.Hash)
+	}
+	return hashes
+}
+
+func chainHashes(blocks []Block) []string {
+	hashes := []string{}
+	for _, block := range blocks {
+		hashes = append(hashes, block.Hash)
+	}
+	return hashes
+}
+
+func TestReorganizeRestoresTheOldBranchWhenTheStoreFails(t *testing.T) {
+	funded, minerA, minerB := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	store := newMemoryStore()
+
+	blockchain, err := NewBlockchainFromStore(newTestGenesisSpec(funded), store, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index := 0; index < 2; index++ {
+		if err := blockchain.AddBlock(mineOn(t, blockchain, &blockchain.Chain[len(blockchain.Chain)-1], minerA)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldChain := chainHashes(blockchain.Blocks())
+
+	// Up to the same height the other branch stays a side chain.
+	branch := []Block{mineOn(t, blockchain, &blockchain.Chain[0], minerB)}
+	for index := 0; index < 2; index++ {
+		if err := blockchain.AddBlock(branch[index]); err != nil {
+			t.Fatal(err)
+		}
+		branch = append(branch, mineOn(t, blockchain, &branch[index], minerB))
+	}
+
+	store.failAppends = 1
+	if err := blockchain.AddBlock(branch[2]); !errors.Is(err, errStoreUnavailable) {
+		t.Fatalf("expected the store failure, got %v", err)
+	}
+
+	if got := chainHashes(blockchain.Blocks()); !reflect.DeepEqual(got, oldChain) {
+		t.Fatalf("in memory chain not restored\n got: %v\nwant: %v", got, oldChain)
+	}
+	if got := storedHashes(store); !reflect.DeepEqual(got, oldChain) {
+		t.Fatalf("stored chain not restored\n got: %v\nwant: %v", got, oldChain)
+	}
+	if blockchain.GetAccountState(minerB.address).Balance != 0 {
+		t.Fatal("the failed branch still pays its miner")
+	}
+
+	// The branch stays known, the next block on top of it switches the chain for good.
+	branch = append(branch, mineOn(t, blockchain, &branch[2], minerB))
+	if err := blockchain.AddBlock(branch[3]); err != nil {
+		t.Fatal(err)
+	}
+
+	newChain := append([]string{oldChain[0]}, chainHashes(branch)...)
+	if got := storedHashes(store); !reflect.DeepEqual(got, newChain) {
+		t.Fatalf("stored chain did not switch\n got: %v\nwant: %v", got, newChain)
+	}
+	if blockchain.GetAccountState(minerA.address).Balance != 0 {
+		t.Fatal("the disconnected branch still pays its miner")
+	}
+	if store.appliedTip != (AppliedTip{Height: 4, Hash: branch[3].Hash}) {
+		t.Fatalf("applied tip not moved to the new tip: %+v", store.appliedTip)
+	}
+}
+
+func TestNewBlockchainFromStoreRebuildsStatesBehindTheTip(t *testing.T) {
+	sender, recipient, miner := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	spec := newTestGenesisSpec(sender)
+	store := newMemoryStore()
+
+	blockchain, err := NewBlockchainFromStore(spec, store, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := blockchain.SubmitBlock(mineBlock(t, blockchain, miner, transfer(t, sender, recipient.address, 10, nonce))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A crash between storing the last block and its account states leaves the states at the previous tip.
+	expected := store.stateList()
+	store.appliedTip = AppliedTip{Height: 1, Hash: store.blocks[1].Hash}
+	stale := store.states[sender.address]
+	stale.Balance += primitives.AmountUnitsPerCoin
+	store.states[sender.address] = stale
+	store.states["orphan"] = AccountState{Address: "orphan", Balance: 1}
+
+	reloaded, err := NewBlockchainFromStore(spec, store, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reloaded.GetAccountState(sender.address); got != blockchain.GetAccountState(sender.address) {
+		t.Fatalf("stale state survived the restart\n got: %+v\nwant: %+v", got, blockchain.GetAccountState(sender.address))
+	}
+	if stored := NewAccountStates(store.stateList()).All(); !reflect.DeepEqual(stored, NewAccountStates(expected).All()) {
+		t.Fatalf("stored states were not rebuilt\n got: %+v\nwant: %+v", stored, expected)
+	}
+	if store.appliedTip != (AppliedTip{Height: 2, Hash: store.blocks[2].Hash}) {
+		t.Fatalf("applied tip not moved to the chain tip: %+v", store.appliedTip)
+	}
+}
+
+func TestNewBlockchainFromStoreRejectsBlocksThatDoNotHashToTheirStoredHash(t *testing.T) {
+	sender, recipient, miner := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	spec := newTestGenesisSpec(sender)
+
+	tamper := map[string]func(blocks []Block){
+		"genesis hash": func(blocks []Block) { blocks[0].Hash = blocks[1].Hash },
+		"tip hash":     func(blocks []Block) { blocks[2].Hash = blocks[1].Hash },
+		"tip nonce":    func(blocks []Block) { blocks[2].Header.Nonce++ },
+		"time stamp":   func(blocks []Block) { blocks[1].Header.TimeStamp = blocks[1].Header.TimeStamp.Add(time.Second) },
+		"merkle root":  func(blocks []Block) { blocks[1].Header.MerkleRoot = blocks[2].Header.MerkleRoot },
+	}
+
+	for name, apply := range tamper {
+		store := newMemoryStore()
+		blockchain, err := NewBlockchainFromStore(spec, store, store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			if err := blockchain.SubmitBlock(mineBlock(t, blockchain, miner, transfer(t, sender, recipient.address, 10, nonce))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		apply(store.blocks)
+		if _, err := NewBlockchainFromStore(spec, store, store); !errors.Is(err, ErrInvalidBlockHeader) {
+			t.Fatalf("%s: got %v, want %v", name, err, ErrInvalidBlockHeader)
+		}
+	}
+}
+
+func TestRepairAccountStatesSavesTheRebuiltStatesUnderTheirTip(t *testing.T) {
+	sender, recipient, miner := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	store := newMemoryStore()
+
+	blockchain, err := NewBlockchainFromStore(newTestGenesisSpec(sender), store, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blockchain.SubmitBlock(mineBlock(t, blockchain, miner, transfer(t, sender, recipient.address, 10, 0))); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupted := store.states[recipient.address]
+	corrupted.Balance++
+	store.states[recipient.address] = corrupted
+	store.states["orphan"] = AccountState{Address: "orphan", Balance: 1}
+
+	diffs, err := blockchain.RepairAccountStates(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 || store.states[recipient.address] != corrupted {
+		t.Fatalf("a check without repair returned %+v and left %+v stored", diffs, store.states[recipient.address])
+	}
+
+	// Blocks connected while the repair runs must not end up with states rebuilt before them.
+	next := mineBlock(t, blockchain, miner, transfer(t, sender, recipient.address, 5, 1))
+	done := make(chan error)
+	go func() { done <- blockchain.SubmitBlock(next) }()
+
+	if _, err := blockchain.RepairAccountStates(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := RebuildAccountStates(blockchain.Blocks(), blockchain.CoinbaseMaturity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := NewAccountStates(store.stateList()).All(); !reflect.DeepEqual(stored, expected.All()) {
+		t.Fatalf("stored states differ from the rebuilt ones\n got: %+v\nwant: %+v", stored, expected.All())
+	}
+	if tip := blockchain.appliedTip(); store.appliedTip != tip {
+		t.Fatalf("states stored under %+v, the chain tip is %+v", store.appliedTip, tip)
+	}
+
+	if diffs, err := blockchain.RepairAccountStates(false); err != nil || len(diffs) != 0 {
+		t.Fatalf("repaired states still differ: %+v, %v", diffs, err)
+	}
+}
+
+func TestDisconnectTipRestoresLastActivityHeight(t *testing.T) {
+	sender, recipient, miner := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	spec := newTestGenesisSpec(sender)
+	store := newMemoryStore()
+
+	blockchain, err := NewBlockchainFromStore(spec, store, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blockchain.SubmitBlock(mineBlock(t, blockchain, miner, transfer(t, sender, recipient.address, 10, 0))); err != nil {
+		t.Fatal(err)
+	}
+	if err := blockchain.SubmitBlock(mineBlock(t, blockchain, miner)); err != nil {
+		t.Fatal(err)
+	}
+	if err := blockchain.SubmitBlock(mineBlock(t, blockchain, newTestAccount(t), transfer(t, sender, recipient.address, 5, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undo records of blocks loaded from the store have to match the ones of blocks connected at runtime.
+	reloaded, err := NewBlockchainFromStore(spec, store, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reloaded.undos, blockchain.undos) {
+		t.Fatalf("derived undo records differ\n got: %+v\nwant: %+v", reloaded.undos, blockchain.undos)
+	}
+
+	for height := len(blockchain.Chain) - 1; height > 0; height-- {
+		if _, err := reloaded.disconnectTip(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := RebuildAccountStates(blockchain.Chain[:height], blockchain.CoinbaseMaturity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := reloaded.accounts.All(); !reflect.DeepEqual(got, expected.All()) {
+			t.Fatalf("height %d: reverted states differ from the rebuilt ones\n got: %+v\nwant: %+v", height-1, got, expected.All())
+		}
+	}
+}
+
+func TestNewBlockTemplateNeverPrecedesTheParent(t *testing.T) {
+	funded, miner := newTestAccount(t), newTestAccount(t)
+
+	blockchain, err := NewBlockchain(newTestGenesisSpec(funded))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The parent was mined by a node whose clock runs ahead of the local one.
+	future := time.Now().Add(MaxFutureBlockTime / 2).UTC().Truncate(time.Millisecond)
+	if err := blockchain.AddBlock(mineAt(t, blockchain, &blockchain.Chain[0], miner, future)); err != nil {
+		t.Fatal(err)
+	}
+
+	template := mineBlock(t, blockchain, miner)
+	if template.Header.TimeStamp.Before(future) {
+		t.Fatalf("template time stamp %v precedes the parent time stamp %v", template.Header.TimeStamp, future)
+	}
+	if err := blockchain.SubmitBlock(template); err != nil {
+		t.Fatalf("the template does not connect: %v", err)
+	}
+}
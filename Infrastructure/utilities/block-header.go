@@ -0,0 +1,186 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	bytes "bytes"
+	ecdsa "crypto/ecdsa"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+	fmt "fmt"
+	big "math/big"
+	time "time"
+)
+
+// BlockHeaderVersion is the only header version this node produces and accepts.
+const BlockHeaderVersion uint32 = 1
+
+// MaxFutureBlockTime is how far a header time stamp may run ahead of the local clock.
+const MaxFutureBlockTime = 2 * time.Hour
+
+var (
+	ErrInvalidBlockHeader    = errors.New("invalid block header")
+	ErrInvalidBlockSignature = errors.New("invalid block signature")
+	ErrSigningKeyNotMiner    = errors.New("the signing key does not own the miner address")
+)
+
+// BlockHeader is everything a block commits to, without the transactions themselves.
+// It can be hashed, validated and stored on its own. MinerPublicKey is the compressed key behind the
+// Miner address, the signature is verified against it.
+type BlockHeader struct {
+	Version        uint32
+	Height         int64
+	PreviousHash   string
+	MerkleRoot     string
+	TimeStamp      time.Time
+	Bits           uint32
+	Nonce          uint64
+	Miner          string
+	MinerPublicKey []byte
+	Signature      []byte
+}
+
+func (header *BlockHeader) CalculateHash() string {
+	return hex.EncodeToString(header.SigningHash())
+}
+
+// HashingBytes returns the canonical encoding of everything the block hash covers, i.e. the
+// header without its signature. The nonce is written last so that mining only has to re-encode the tail.
+func (header *BlockHeader) HashingBytes() []byte {
+	encoder := primitives.NewCanonicalEncoder(primitives.CanonicalBlockHeaderTag)
+	header.writeHashingFields(encoder)
+	return encoder.Bytes()
+}
+
+// MarshalCanonical returns the canonical encoding of the signed header.
+func (header *BlockHeader) MarshalCanonical() []byte {
+	encoder := primitives.NewCanonicalEncoder(primitives.CanonicalBlockHeaderTag)
+	header.writeHashingFields(encoder)
+	encoder.WriteBytes(header.Signature)
+	return encoder.Bytes()
+}
+
+func UnmarshalCanonicalBlockHeader(data []byte) (BlockHeader, error) {
+	decoder := primitives.NewCanonicalDecoder(data, primitives.CanonicalBlockHeaderTag)
+	header := BlockHeader{
+		Version:        decoder.ReadUint32(),
+		Height:         decoder.ReadInt64(),
+		PreviousHash:   decoder.ReadString(),
+		MerkleRoot:     decoder.ReadString(),
+		TimeStamp:      decoder.ReadTime(),
+		Bits:           decoder.ReadUint32(),
+		Miner:          decoder.ReadString(),
+		MinerPublicKey: decoder.ReadBytes(),
+		Nonce:          decoder.ReadUint64(),
+		Signature:      decoder.ReadBytes(),
+	}
+
+	if err := decoder.Finish(); err != nil {
+		return BlockHeader{}, err
+	}
+
+	return header, nil
+}
+
+func (header *BlockHeader) writeHashingFields(encoder *primitives.CanonicalEncoder) {
+	encoder.WriteUint32(header.Version)
+	encoder.WriteInt64(header.Height)
+	encoder.WriteString(header.PreviousHash)
+	encoder.WriteString(header.MerkleRoot)
+	encoder.WriteTime(header.TimeStamp)
+	encoder.WriteUint32(header.Bits)
+	encoder.WriteString(header.Miner)
+	encoder.WriteBytes(header.MinerPublicKey)
+	encoder.WriteUint64(header.Nonce)
+}
+
+// Target returns the proof of work target encoded in Bits.
+func (header *BlockHeader) Target() *big.Int {
+	return CompactToTarget(header.Bits)
+}
+
+func (header *BlockHeader) HasValidProofOfWork() bool {
+	return hashMeetsTarget(header.CalculateHash(), header.Target())
+}
+
+// ValidateBlockHeader checks everything about a header that can be checked without its transactions.
+func ValidateBlockHeader(header *BlockHeader, parent *BlockHeader, now time.Time) error {
+	if header.Version != BlockHeaderVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBlockHeader, header.Version)
+	}
+
+	if header.Height != parent.Height+1 {
+		return fmt.Errorf("%w: height %d does not follow parent height %d", ErrInvalidBlockHeader, header.Height, parent.Height)
+	}
+
+	if header.PreviousHash != parent.CalculateHash() {
+		return fmt.Errorf("%w: previous hash does not match the parent", ErrInvalidBlockHeader)
+	}
+
+	if header.TimeStamp.Before(parent.TimeStamp) {
+		return fmt.Errorf("%w: time stamp is before the parent time stamp", ErrInvalidBlockHeader)
+	}
+
+	if header.TimeStamp.After(now.Add(MaxFutureBlockTime)) {
+		return fmt.Errorf("%w: time stamp is too far in the future", ErrInvalidBlockHeader)
+	}
+
+	if !header.HasValidProofOfWork() {
+		return fmt.Errorf("%w: hash does not meet the target", ErrInvalidBlockHeader)
+	}
+
+	if err := header.VerifySignature(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBlockHeader, err)
+	}
+
+	return nil
+}
+
+// SigningHash is what the miner signs, the raw header hash. It covers every header field but the signature.
+func (header *BlockHeader) SigningHash() []byte {
+	hash := sha256.Sum256(header.HashingBytes())
+	return hash[:]
+}
+
+// Sign signs the header with the key that owns the Miner address. The key has to be set as MinerPublicKey
+// before mining, because the block hash covers it.
+func (header *BlockHeader) Sign(signingKey *ecdsa.PrivateKey) error {
+	if !header.isMinedBy(signingKey) || !bytes.Equal(primitives.MarshalPublicKey(&signingKey.PublicKey), header.MinerPublicKey) {
+		return ErrSigningKeyNotMiner
+	}
+
+	signature, err := primitives.SignHash(signingKey, header.SigningHash())
+	if err != nil {
+		return err
+	}
+
+	header.Signature = signature
+	return nil
+}
+
+func (header *BlockHeader) isMinedBy(signingKey *ecdsa.PrivateKey) bool {
+	return signingKey != nil && primitives.PublicKeyToAddress(&signingKey.PublicKey) == header.Miner
+}
+
+// VerifySignature checks that the header was signed by the key behind its Miner address.
+func (header *BlockHeader) VerifySignature() error {
+	if len(header.Signature) != primitives.SignatureLength {
+		return fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidBlockSignature, primitives.SignatureLength, len(header.Signature))
+	}
+
+	minerKey, err := primitives.VerifyPublicKeyOwnsAddress(header.MinerPublicKey, header.Miner)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBlockSignature, err)
+	}
+
+	if !primitives.VerifySignature(minerKey, header.SigningHash(), header.Signature) {
+		return fmt.Errorf("%w: not signed by the miner", ErrInvalidBlockSignature)
+	}
+
+	return nil
+}
+
+// IsBlockMiner reports whether the block was mined and signed by the given address.
+func (header *BlockHeader) IsBlockMiner(minerAddress string) bool {
+	return header.Miner == minerAddress && header.VerifySignature() == nil
+}
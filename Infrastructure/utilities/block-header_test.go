@@ -0,0 +1,105 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	context "context"
+	errors "errors"
+	big "math/big"
+	testing "testing"
+	time "time"
+)
+
+// minedHeader is a header mined and signed by miner on an easy target.
+func minedHeader(t *testing.T, miner testAccount) BlockHeader {
+	t.Helper()
+
+	block := NewBlock(1, time.Now(), nil, "parent", miner.address, 0x207fffff)
+	if err := block.MineBlock(context.Background(), miner.key, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	return block.Header
+}
+
+// verifyWithoutPanic turns a panic of VerifySignature into a test failure, so every fixture is checked.
+func verifyWithoutPanic(t *testing.T, name string, header *BlockHeader) (err error) {
+	t.Helper()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			t.Errorf("%s: VerifySignature panicked: %v", name, recovered)
+			err = ErrInvalidBlockSignature
+		}
+	}()
+	return header.VerifySignature()
+}
+
+func TestBlockHeaderVerifySignatureRejectsTamperedHeaders(t *testing.T) {
+	miner, other := newTestAccount(t), newTestAccount(t)
+	header := minedHeader(t, miner)
+
+	if err := header.VerifySignature(); err != nil {
+		t.Fatalf("the mined header does not verify: %v", err)
+	}
+
+	n := miner.key.Curve.Params().N
+	tests := []struct {
+		name   string
+		tamper func(header *BlockHeader)
+	}{
+		{"flipped nonce", func(header *BlockHeader) { header.Nonce ^= 1 }},
+		{"flipped merkle root", func(header *BlockHeader) {
+			flipped := []byte(header.MerkleRoot)
+			flipped[0] ^= 0x01
+			header.MerkleRoot = string(flipped)
+		}},
+		{"public key of another address", func(header *BlockHeader) {
+			header.MinerPublicKey = primitives.MarshalPublicKey(&other.key.PublicKey)
+		}},
+		{"miner address of another key", func(header *BlockHeader) { header.Miner = other.address }},
+		{"truncated signature", func(header *BlockHeader) { header.Signature = header.Signature[:len(header.Signature)-1] }},
+		{"empty signature", func(header *BlockHeader) { header.Signature = nil }},
+		{"high S signature", func(header *BlockHeader) {
+			s := new(big.Int).SetBytes(header.Signature[primitives.SignatureLength/2:])
+			highS := append([]byte{}, header.Signature...)
+			new(big.Int).Sub(n, s).FillBytes(highS[primitives.SignatureLength/2:])
+			header.Signature = highS
+		}},
+		{"zero signature", func(header *BlockHeader) { header.Signature = make([]byte, primitives.SignatureLength) }},
+		{"nil public key", func(header *BlockHeader) { header.MinerPublicKey = nil }},
+		{"public key off the curve", func(header *BlockHeader) {
+			header.MinerPublicKey = append([]byte{0x02}, make([]byte, primitives.CompressedPublicKeyLength-1)...)
+		}},
+		{"uncompressed public key", func(header *BlockHeader) {
+			header.MinerPublicKey = append([]byte{0x04}, make([]byte, 64)...)
+		}},
+	}
+
+	for _, test := range tests {
+		tampered := header
+		tampered.Signature = append([]byte{}, header.Signature...)
+		test.tamper(&tampered)
+
+		err := verifyWithoutPanic(t, test.name, &tampered)
+		if !errors.Is(err, ErrInvalidBlockSignature) {
+			t.Errorf("%s: expected ErrInvalidBlockSignature, got %v", test.name, err)
+		}
+		if tampered.IsBlockMiner(tampered.Miner) {
+			t.Errorf("%s: the tampered header still counts as mined by %s", test.name, tampered.Miner)
+		}
+	}
+
+	if err := header.VerifySignature(); err != nil {
+		t.Fatalf("tampering changed the original header: %v", err)
+	}
+}
+
+func TestBlockHeaderRejectsMissingKeys(t *testing.T) {
+	if err := verifyWithoutPanic(t, "empty header", &BlockHeader{}); !errors.Is(err, ErrInvalidBlockSignature) {
+		t.Fatalf("expected ErrInvalidBlockSignature, got %v", err)
+	}
+
+	header := minedHeader(t, newTestAccount(t))
+	if err := header.Sign(nil); !errors.Is(err, ErrSigningKeyNotMiner) {
+		t.Fatalf("expected ErrSigningKeyNotMiner for a nil key, got %v", err)
+	}
+}
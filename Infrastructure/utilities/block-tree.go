@@ -0,0 +1,131 @@
+package utilities
+
+import (
+	errors "errors"
+	big "math/big"
+)
+
+var (
+	ErrUnknownParent     = errors.New("parent block is not known")
+	ErrBlockAlreadyKnown = errors.New("block is already known")
+)
+
+// BlockTreeNode is a block in the tree together with the total work of the branch it ends.
+type BlockTreeNode struct {
+	Block          Block
+	Parent         *BlockTreeNode
+	CumulativeWork *big.Int
+	children       []*BlockTreeNode
+}
+
+// BlockTree keeps every known block that descends from genesis, including the ones on side chains.
+// Only the canonical branch is stored, side chains live in memory.
+type BlockTree struct {
+	genesis *BlockTreeNode
+	nodes   map[string]*BlockTreeNode
+}
+
+func NewBlockTree(genesisBlock Block) *BlockTree {
+	genesis := &BlockTreeNode{
+		Block:          genesisBlock,
+		CumulativeWork: BlockWork(genesisBlock.Header.Bits),
+	}
+
+	return &BlockTree{
+		genesis: genesis,
+		nodes:   map[string]*BlockTreeNode{genesisBlock.Hash: genesis},
+	}
+}
+
+// Add links a block to its parent. The block itself is not validated.
+func (tree *BlockTree) Add(block Block) (*BlockTreeNode, error) {
+	if _, ok := tree.nodes[block.Hash]; ok {
+		return nil, ErrBlockAlreadyKnown
+	}
+
+	parent, ok := tree.nodes[block.Header.PreviousHash]
+	if !ok {
+		return nil, ErrUnknownParent
+	}
+
+	node := &BlockTreeNode{
+		Block:          block,
+		Parent:         parent,
+		CumulativeWork: new(big.Int).Add(parent.CumulativeWork, BlockWork(block.Header.Bits)),
+	}
+	tree.nodes[block.Hash] = node
+	parent.children = append(parent.children, node)
+	return node, nil
+}
+
+func (tree *BlockTree) Get(hash string) (*BlockTreeNode, bool) {
+	node, ok := tree.nodes[hash]
+	return node, ok
+}
+
+// Remove drops a block and everything built on top of it, e.g. once the block turned out to be invalid.
+// It only visits the removed blocks, through the children of each node.
+func (tree *BlockTree) Remove(hash string) {
+	node, ok := tree.nodes[hash]
+	if !ok || node == tree.genesis {
+		return
+	}
+
+	siblings := node.Parent.children
+	for index := range siblings {
+		if siblings[index] == node {
+			node.Parent.children = append(siblings[:index:index], siblings[index+1:]...)
+			break
+		}
+	}
+
+	removed := []*BlockTreeNode{node}
+	for len(removed) > 0 {
+		current := removed[len(removed)-1]
+		removed = append(removed[:len(removed)-1], current.children...)
+		delete(tree.nodes, current.Block.Hash)
+	}
+}
+
+func (node *BlockTreeNode) Height() int64 {
+	return node.Block.Header.Height
+}
+
+// Ancestor walks down the branch to the block at the given height.
+func (node *BlockTreeNode) Ancestor(height int64) *BlockTreeNode {
+	if height < 0 || height > node.Height() {
+		return nil
+	}
+
+	for node.Height() > height {
+		node = node.Parent
+	}
+	return node
+}
+
+// FindForkPoint returns the last block two branches have in common.
+func FindForkPoint(first *BlockTreeNode, second *BlockTreeNode) *BlockTreeNode {
+	for first.Height() > second.Height() {
+		first = first.Parent
+	}
+	for second.Height() > first.Height() {
+		second = second.Parent
+	}
+
+	for first != second {
+		first = first.Parent
+		second = second.Parent
+	}
+	return first
+}
+
+// BlockWork is the expected number of hashes needed to meet the target, 2^256 / (target + 1).
+func BlockWork(bits uint32) *big.Int {
+	target := CompactToTarget(bits)
+	if target.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	denominator := new(big.Int).Add(target, big.NewInt(1))
+	return new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), 256), denominator)
+}
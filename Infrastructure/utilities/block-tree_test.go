@@ -0,0 +1,73 @@
+package utilities
+
+import (
+	strconv "strconv"
+	testing "testing"
+)
+
+func testTreeBlock(hash string, parent string, height int64) Block {
+	return Block{Hash: hash, Header: BlockHeader{PreviousHash: parent, Height: height, Bits: 0x207fffff}}
+}
+
+func TestBlockTreeRemoveDropsDescendantsOnly(t *testing.T) {
+	tree := NewBlockTree(testTreeBlock("genesis", "", 0))
+
+	//         genesis
+	//         /     \
+	//        a       b
+	//       / \      |
+	//     a1   a2    b1
+	//     |
+	//    a11
+	for _, block := range []Block{
+		testTreeBlock("a", "genesis", 1),
+		testTreeBlock("b", "genesis", 1),
+		testTreeBlock("a1", "a", 2),
+		testTreeBlock("a2", "a", 2),
+		testTreeBlock("b1", "b", 2),
+		testTreeBlock("a11", "a1", 3),
+	} {
+		if _, err := tree.Add(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tree.Remove("a1")
+	for _, hash := range []string{"a1", "a11"} {
+		if _, ok := tree.Get(hash); ok {
+			t.Fatalf("%s survived the removal of a1", hash)
+		}
+	}
+	for _, hash := range []string{"genesis", "a", "a2", "b", "b1"} {
+		if _, ok := tree.Get(hash); !ok {
+			t.Fatalf("%s was removed with a1", hash)
+		}
+	}
+
+	// A removed block can be added again, and its parent only keeps the children that are still there.
+	if _, err := tree.Add(testTreeBlock("a1", "a", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if node, _ := tree.Get("a"); len(node.children) != 2 {
+		t.Fatalf("a has %d children, want 2", len(node.children))
+	}
+
+	tree.Remove("genesis")
+	if _, ok := tree.Get("genesis"); !ok {
+		t.Fatal("genesis was removed")
+	}
+}
+
+func TestBlockTreeRemoveLongBranch(t *testing.T) {
+	tree := NewBlockTree(testTreeBlock("0", "", 0))
+	for height := int64(1); height <= 10_000; height++ {
+		if _, err := tree.Add(testTreeBlock(strconv.FormatInt(height, 10), strconv.FormatInt(height-1, 10), height)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tree.Remove("1")
+	if len(tree.nodes) != 1 {
+		t.Fatalf("%d blocks left, want only genesis", len(tree.nodes))
+	}
+}
@@ -1,153 +1,174 @@
 package utilities
 
 import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	context "context"
 	ecdsa "crypto/ecdsa"
-	elliptic "crypto/elliptic"
-	rand "crypto/rand"
-	sha256 "crypto/sha256"
-	hex "encoding/hex"
+	errors "errors"
 	fmt "fmt"
-	hash "hash"
-	big "math/big"
-	strings "strings"
 	time "time"
 )
 
 type BlockService interface {
 	CalculateHash() string
-	GetTransactionsStringData() string
+	MarshalCanonical() []byte
 	HasValidTransactions() bool
-	MineBlock(difficulty int64)
-	IsBlockSignatureValid() bool
-	IsBlockMiner(minerAddress string) bool
-	GetHash(hashAlgorithm hash.Hash, input string) string
+	HasValidMerkleRoot() bool
+	MineBlock(ctx context.Context, signingKey *ecdsa.PrivateKey, workers int, report func(progress MiningProgress)) error
 }
 
+// Block is a header plus the transactions it commits to. Hash caches Header.CalculateHash().
 type Block struct {
-	TimeStamp      time.Time
-	Transactions   []BlockTransaction
-	PreviousHash   string
-	Hash           string
-	Nonce          int
-	BlockMiner     string
-	BlockSignature []byte
-	SigningKey     *ecdsa.PrivateKey
+	Header       BlockHeader
+	Transactions []primitives.BlockTransaction
+	Hash         string
 }
 
-func NewBlock(timeStamp time.Time, transactions []BlockTransaction, previousHash string, miningRewardAddress string, signingKey *ecdsa.PrivateKey) *Block {
+func NewBlock(height int64, timeStamp time.Time, transactions []primitives.BlockTransaction, previousHash string, miningRewardAddress string, bits uint32) *Block {
 	block := &Block{
-		TimeStamp:    timeStamp,
+		Header: BlockHeader{
+			Version:      BlockHeaderVersion,
+			Height:       height,
+			PreviousHash: previousHash,
+			MerkleRoot:   ComputeMerkleRoot(transactions),
+			TimeStamp:    timeStamp,
+			Bits:         bits,
+			Nonce:        0,
+			Miner:        miningRewardAddress,
+		},
 		Transactions: transactions,
-		PreviousHash: previousHash,
-		Nonce:        0,
-		BlockMiner:   miningRewardAddress,
-		SigningKey:   signingKey,
 	}
 	block.Hash = block.CalculateHash()
 	return block
 }
 
 func (block *Block) CalculateHash() string {
-	sha256Hash := sha256.New()
-	transactionsStringData := block.GetTransactionsStringData()
-	data := fmt.Sprintf("%v%v%v%v", block.TimeStamp, block.PreviousHash, transactionsStringData, block.Nonce)
-	return GetHash(sha256Hash, data)
+	return block.Header.CalculateHash()
 }
 
-func (block *Block) GetTransactionsStringData() string {
-	var transactionsStringData strings.Builder
-	for _, transaction := range block.Transactions {
-		transactionsStringData.WriteString(hex.EncodeToString(transaction.Signature))
-	}
-
-	return transactionsStringData.String()
-}
+// MarshalCanonical returns the canonical encoding of the mined and signed block for wire transfer.
+func (block *Block) MarshalCanonical() []byte {
+	encoder := primitives.NewCanonicalEncoder(primitives.CanonicalBlockTag)
+	encoder.WriteBytes(block.Header.MarshalCanonical())
 
-func (block *Block) HasValidTransactions() bool {
-	for _, tx := range block.Transactions {
-		if !tx.IsValid() {
-			return false
-		}
+	encoder.WriteUint32(uint32(len(block.Transactions)))
+	for index := range block.Transactions {
+		encoder.WriteBytes(block.Transactions[index].MarshalCanonical())
 	}
 
-	return true
+	return encoder.Bytes()
 }
 
-func (block *Block) MineBlock(difficulty int) {
-	stopWatch := time.Now()
+func UnmarshalCanonicalBlock(data []byte) (Block, error) {
+	decoder := primitives.NewCanonicalDecoder(data, primitives.CanonicalBlockTag)
+
+	header, err := UnmarshalCanonicalBlockHeader(decoder.ReadBytes())
+	if err != nil {
+		return Block{}, err
+	}
 
-	for !isValidHash(block.Hash, difficulty) {
-		block.Nonce++
-		block.Hash = block.CalculateHash()
+	transactionCount := decoder.ReadUint32()
+	if uint64(transactionCount) > uint64(len(data)) {
+		return Block{}, primitives.ErrCanonicalEncodingTruncated
 	}
 
-	r, s, err := ecdsa.Sign(rand.Reader, block.SigningKey, []byte(block.Hash))
-	if err != nil {
-		// Handle error
-		return
+	block := Block{
+		Header:       header,
+		Transactions: make([]primitives.BlockTransaction, 0, transactionCount),
 	}
 
-	block.BlockSignature = append(r.Bytes(), s.Bytes()...)
-	result := block.IsBlockSignatureValid()
+	for index := uint32(0); index < transactionCount; index++ {
+		transaction, err := primitives.UnmarshalCanonicalTransaction(decoder.ReadBytes())
+		if err != nil {
+			return Block{}, err
+		}
+		block.Transactions = append(block.Transactions, transaction)
+	}
 
-	if result {
-		fmt.Printf("Block signature valid: %v\n", block.BlockSignature)
+	if err := decoder.Finish(); err != nil {
+		return Block{}, err
 	}
 
-	elapsed := time.Since(stopWatch)
-	fmt.Printf("Mining time: %v for block: %v\n", elapsed, block.Hash)
+	block.Hash = block.CalculateHash()
+	return block, nil
 }
 
-func isValidHash(hash string, difficulty int) bool {
-	prefix := strings.Repeat("0", difficulty)
-	return strings.HasPrefix(hash, prefix)
+// HasValidMerkleRoot checks that the merkle root in the header commits to the transactions of the block.
+func (block *Block) HasValidMerkleRoot() bool {
+	return block.Header.MerkleRoot == ComputeMerkleRoot(block.Transactions)
 }
 
-func (block *Block) IsBlockSignatureValid() bool {
-	minerKey := &ecdsa.PublicKey{
-		Curve: elliptic.P256(),
-		X:     new(big.Int),
-		Y:     new(big.Int),
+// GetTransactionProof returns the inclusion proof of a transaction in this block.
+func (block *Block) GetTransactionProof(transactionId string) (*MerkleProof, error) {
+	for index := range block.Transactions {
+		if block.Transactions[index].TransactionId() == transactionId {
+			return BuildMerkleProof(block.Transactions, index)
+		}
 	}
-	minerKey.X, minerKey.Y = minerKey.Curve.ScalarBaseMult(block.BlockSignature)
 
-	if block.BlockSignature == nil || len(block.BlockSignature) != 64 {
-		panic("Block doesn't contain a valid block signature.")
+	return nil, errors.New("transaction is not part of this block")
+}
+
+// HasValidTransactions verifies the signature of every transfer. The coinbase is checked by ValidateCoinbase.
+func (block *Block) HasValidTransactions() bool {
+	for _, tx := range block.Transactions {
+		if tx.Kind == primitives.TransactionKindCoinbase {
+			continue
+		}
+
+		if !tx.IsValid() {
+			return false
+		}
 	}
 
-	randomCordinate := new(big.Int).SetBytes(block.BlockSignature[:32])
-	secret := new(big.Int).SetBytes(block.BlockSignature[32:])
+	return true
+}
+
+// MaxBlockSize is the largest canonical encoding of a block a node reads from a peer. A full template of
+// the default template size stays well below it.
+const MaxBlockSize = 1 << 20
+
+// MiningReportInterval is the number of hashes a mining worker does between two progress reports and cancellation checks.
+const MiningReportInterval uint64 = 1 << 14
 
-	return ecdsa.Verify(minerKey, []byte(block.Hash), randomCordinate, secret)
+// MiningProgress is reported while a block is mined. Hashes counts the hashes since the previous report,
+// Elapsed the time since mining of the block started.
+type MiningProgress struct {
+	Height  int64
+	Hashes  uint64
+	Elapsed time.Duration
 }
 
-func (block *Block) IsBlockMiner(minerAddress string) bool {
-	minerKeyBytes, err := hex.DecodeString(minerAddress)
-	if err != nil {
-		panic("Invalid miner address.")
+// MineBlock commits the header to the public key of the signing key, searches a nonce that satisfies the
+// header target on the given number of workers, one per GOMAXPROCS when zero, and signs the header. The
+// private key is only used here, it never becomes part of the block. A key that does not own the miner
+// address is rejected before any work is done. The search stops with the context's error once it is
+// cancelled. report, when not nil, is called concurrently by the workers every MiningReportInterval hashes.
+func (block *Block) MineBlock(ctx context.Context, signingKey *ecdsa.PrivateKey, workers int, report func(progress MiningProgress)) error {
+	if !block.Header.isMinedBy(signingKey) {
+		return ErrSigningKeyNotMiner
 	}
 
-	if block.BlockSignature == nil || len(block.BlockSignature) != 65 {
-		panic("Block doesn't contain a valid block signature.")
+	stopWatch := time.Now()
+
+	block.Header.MinerPublicKey = primitives.MarshalPublicKey(&signingKey.PublicKey)
+	nonce, err := searchNonce(ctx, &block.Header, workers, func(hashes uint64) {
+		if report != nil {
+			report(MiningProgress{Height: block.Header.Height, Hashes: hashes, Elapsed: time.Since(stopWatch)})
+		}
+	})
+	if err != nil {
+		return err
 	}
 
-	// Extract r and s components from the signature
-	randomCoordinate := new(big.Int).SetBytes(block.BlockSignature[:32])
-	secret := new(big.Int).SetBytes(block.BlockSignature[32:])
+	block.Header.Nonce = nonce
+	block.Hash = block.CalculateHash()
 
-	// Decode the minerKeyBytes into an ecdsa.PublicKey
-	minerKey := &ecdsa.PublicKey{
-		Curve: elliptic.P256(),
-		X:     new(big.Int),
-		Y:     new(big.Int),
+	if err := block.Header.Sign(signingKey); err != nil {
+		return err
 	}
-	minerKey.X, minerKey.Y = elliptic.Unmarshal(minerKey.Curve, minerKeyBytes)
-
-	return ecdsa.Verify(minerKey, []byte(block.Hash), randomCoordinate, secret)
-}
 
-func GetHash(hashAlgorithm hash.Hash, input string) string {
-	hashAlgorithm.Write([]byte(input))
-	hash := hashAlgorithm.Sum(nil)
-	return hex.EncodeToString(hash)
+	elapsed := time.Since(stopWatch)
+	fmt.Printf("Mining time: %v for block: %v\n", elapsed, block.Hash)
+	return nil
 }
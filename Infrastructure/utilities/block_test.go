@@ -0,0 +1,183 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	hex "encoding/hex"
+	errors "errors"
+	reflect "reflect"
+	testing "testing"
+	time "time"
+)
+
+// The golden encodings below must only change together with primitives.CanonicalEncodingVersion.
+const (
+	goldenHeaderHex = "" +
+		"01" + "03" + // version, header tag
+		"00000001" + // header version
+		"0000000000000002" + // height
+		"00000008" + "70726576696f7573" + // previous hash: "previous"
+		"00000006" + "6d65726b6c65" + // merkle root: "merkle"
+		"0000018bcfe56800" + // time stamp: 1700000000000 ms
+		"1d00ffff" + // bits
+		"00000005" + "6d696e6572" + // miner: "miner"
+		"00000002" + "0207" + // miner public key
+		"000000000000002a" + // nonce
+		"00000002" + "0809" // signature
+
+	goldenTransactionHex = "" +
+		"01" + "01" + // version, transaction tag
+		"00000001" + // kind: coinbase
+		"00000000" + // no sender
+		"00000000" + // no public key
+		"00000009" + "726563697069656e74" + // to address: "recipient"
+		"000000012a05f200" + // amount: 50 coins
+		"0000000000000000" + // fee
+		"0000000000000002" + // nonce: the height
+		"00000000" // no signature
+
+	goldenBlockHex = "" +
+		"01" + "02" + // version, block tag
+		"0000004d" + goldenHeaderHex +
+		"00000001" + // transaction count
+		"00000037" + goldenTransactionHex
+)
+
+func goldenBlock() Block {
+	block := Block{
+		Header: BlockHeader{
+			Version:        BlockHeaderVersion,
+			Height:         2,
+			PreviousHash:   "previous",
+			MerkleRoot:     "merkle",
+			TimeStamp:      time.UnixMilli(1_700_000_000_000).UTC(),
+			Bits:           0x1d00ffff,
+			Miner:          "miner",
+			MinerPublicKey: []byte{0x02, 0x07},
+			Nonce:          42,
+			Signature:      []byte{0x08, 0x09},
+		},
+		Transactions: []primitives.BlockTransaction{{
+			Kind:      primitives.TransactionKindCoinbase,
+			ToAddress: "recipient",
+			Amount:    50 * primitives.AmountUnitsPerCoin,
+			Nonce:     2,
+		}},
+	}
+	block.Hash = block.CalculateHash()
+	return block
+}
+
+func TestBlockHeaderCanonicalEncodingGolden(t *testing.T) {
+	header := goldenBlock().Header
+
+	encoded := hex.EncodeToString(header.MarshalCanonical())
+	if encoded != goldenHeaderHex {
+		t.Fatalf("encoding changed\n got: %s\nwant: %s", encoded, goldenHeaderHex)
+	}
+
+	// The hash covers everything but the trailing signature.
+	hashingBytes := hex.EncodeToString(header.HashingBytes())
+	if want := goldenHeaderHex[:len(goldenHeaderHex)-len("000000020809")]; hashingBytes != want {
+		t.Fatalf("hashing bytes changed\n got: %s\nwant: %s", hashingBytes, want)
+	}
+
+	data, _ := hex.DecodeString(goldenHeaderHex)
+	decoded, err := UnmarshalCanonicalBlockHeader(data)
+	if err != nil {
+		t.Fatalf("decoding the golden header failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, header) {
+		t.Fatalf("round trip changed the header\n got: %+v\nwant: %+v", decoded, header)
+	}
+}
+
+func TestBlockCanonicalEncodingGolden(t *testing.T) {
+	block := goldenBlock()
+
+	encoded := hex.EncodeToString(block.MarshalCanonical())
+	if encoded != goldenBlockHex {
+		t.Fatalf("encoding changed\n got: %s\nwant: %s", encoded, goldenBlockHex)
+	}
+
+	data, _ := hex.DecodeString(goldenBlockHex)
+	decoded, err := UnmarshalCanonicalBlock(data)
+	if err != nil {
+		t.Fatalf("decoding the golden block failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, block) {
+		t.Fatalf("round trip changed the block\n got: %+v\nwant: %+v", decoded, block)
+	}
+}
+
+func TestUnmarshalCanonicalBlockRejectsMalformedInput(t *testing.T) {
+	golden, _ := hex.DecodeString(goldenBlockHex)
+
+	for length := 0; length < len(golden); length++ {
+		if _, err := UnmarshalCanonicalBlock(golden[:length]); err == nil {
+			t.Fatalf("decoded a block truncated to %d of %d bytes", length, len(golden))
+		}
+	}
+
+	headerStart := 2 + 4
+	transactionCountStart := headerStart + 0x4d
+	tests := []struct {
+		name   string
+		mutate func(data []byte) []byte
+	}{
+		{"trailing byte", func(data []byte) []byte { return append(data, 0x00) }},
+		{"wrong version", func(data []byte) []byte { data[0] = primitives.CanonicalEncodingVersion + 1; return data }},
+		{"transaction tag", func(data []byte) []byte { data[1] = primitives.CanonicalTransactionTag; return data }},
+		{"wrong header version", func(data []byte) []byte { data[headerStart] = primitives.CanonicalEncodingVersion - 1; return data }},
+		{"header tag", func(data []byte) []byte { data[headerStart+1] = primitives.CanonicalBlockTag; return data }},
+		{"transaction count too high", func(data []byte) []byte { data[transactionCountStart+3] = 0x02; return data }},
+		{"transaction count too low", func(data []byte) []byte { data[transactionCountStart+3] = 0x00; return data }},
+		{"huge transaction count", func(data []byte) []byte { data[transactionCountStart] = 0xff; return data }},
+	}
+
+	for _, test := range tests {
+		data := test.mutate(append([]byte{}, golden...))
+		if _, err := UnmarshalCanonicalBlock(data); err == nil {
+			t.Errorf("%s: decoded %x", test.name, data)
+		}
+	}
+}
+
+func TestUnmarshalCanonicalBlockHeaderRejectsMalformedInput(t *testing.T) {
+	golden, _ := hex.DecodeString(goldenHeaderHex)
+
+	for length := 0; length < len(golden); length++ {
+		if _, err := UnmarshalCanonicalBlockHeader(golden[:length]); err == nil {
+			t.Fatalf("decoded a header truncated to %d of %d bytes", length, len(golden))
+		}
+	}
+
+	if _, err := UnmarshalCanonicalBlockHeader(append(golden, 0x00)); err == nil {
+		t.Fatal("decoded a header with a trailing byte")
+	}
+
+	wrongVersion := append([]byte{}, golden...)
+	wrongVersion[0] = primitives.CanonicalEncodingVersion + 1
+	if _, err := UnmarshalCanonicalBlockHeader(wrongVersion); err == nil {
+		t.Fatal("decoded a header with an unknown encoding version")
+	}
+}
+
+func TestMineBlockRejectsForeignKeyBeforeSearching(t *testing.T) {
+	miner, other := newTestAccount(t), newTestAccount(t)
+
+	// No nonce meets this target, a search would only end with the deadline.
+	block := NewBlock(1, time.Now(), nil, "parent", miner.address, 0x03000001)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, key := range []*ecdsa.PrivateKey{other.key, nil} {
+		if err := block.MineBlock(ctx, key, 1, nil); !errors.Is(err, ErrSigningKeyNotMiner) {
+			t.Fatalf("expected ErrSigningKeyNotMiner, got %v", err)
+		}
+	}
+	if ctx.Err() != nil || block.Header.MinerPublicKey != nil {
+		t.Fatal("the nonce search ran for a foreign key")
+	}
+}
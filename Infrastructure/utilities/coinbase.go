@@ -0,0 +1,117 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	errors "errors"
+	fmt "fmt"
+)
+
+var (
+	ErrInvalidCoinbase  = errors.New("invalid coinbase transaction")
+	ErrImmatureCoinbase = errors.New("coinbase reward is not mature yet")
+)
+
+// NewCoinbaseTransaction creates the first transaction of the block at the given height, which pays the
+// miner the block reward plus the fees of every other transaction in the block. A coinbase has no sender,
+// its nonce carries the block height instead, so no two coinbase transactions share a hash.
+func NewCoinbaseTransaction(height int64, miningRewardAddress string, reward primitives.Amount, transactions []primitives.BlockTransaction) (primitives.BlockTransaction, error) {
+	fees, err := TotalFees(transactions)
+	if err != nil {
+		return primitives.BlockTransaction{}, err
+	}
+
+	value, err := reward.Add(fees)
+	if err != nil {
+		return primitives.BlockTransaction{}, err
+	}
+
+	return primitives.BlockTransaction{
+		Kind:      primitives.TransactionKindCoinbase,
+		ToAddress: miningRewardAddress,
+		Amount:    value,
+		Nonce:     uint64(height),
+	}, nil
+}
+
+// TotalFees sums the fees of the transactions.
+func TotalFees(transactions []primitives.BlockTransaction) (primitives.Amount, error) {
+	var total primitives.Amount
+	var err error
+
+	for index := range transactions {
+		if total, err = total.Add(transactions[index].Fee); err != nil {
+			return 0, err
+		}
+	}
+
+	return total, nil
+}
+
+// ValidateCoinbase checks that the block starts with its coinbase, that every other transaction is a
+// transfer, and that the coinbase pays exactly the block reward plus the fees collected in the block.
+func ValidateCoinbase(block *Block, reward primitives.Amount) error {
+	if len(block.Transactions) == 0 || block.Transactions[0].Kind != primitives.TransactionKindCoinbase {
+		return fmt.Errorf("%w: the first transaction has to be the coinbase", ErrInvalidCoinbase)
+	}
+
+	coinbase := &block.Transactions[0]
+	if coinbase.FromAddress != "" || len(coinbase.PublicKey) != 0 || coinbase.Fee != 0 || len(coinbase.Signature) != 0 {
+		return fmt.Errorf("%w: a coinbase only pays an amount to a recipient", ErrInvalidCoinbase)
+	}
+
+	if err := primitives.ValidateAddress(coinbase.ToAddress); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCoinbase, err)
+	}
+
+	if coinbase.Nonce != uint64(block.Header.Height) {
+		return fmt.Errorf("%w: carries height %d in block %d", ErrInvalidCoinbase, coinbase.Nonce, block.Header.Height)
+	}
+
+	for index := 1; index < len(block.Transactions); index++ {
+		if block.Transactions[index].Kind != primitives.TransactionKindTransfer {
+			return fmt.Errorf("%w: only the first transaction may create coins", ErrInvalidCoinbase)
+		}
+	}
+
+	fees, err := TotalFees(block.Transactions[1:])
+	if err != nil {
+		return err
+	}
+
+	expected, err := reward.Add(fees)
+	if err != nil {
+		return err
+	}
+
+	if coinbase.Amount != expected {
+		return fmt.Errorf("%w: pays %s, expected reward %s plus fees %s", ErrInvalidCoinbase, coinbase.Amount, reward, fees)
+	}
+
+	return nil
+}
+
+// ImmatureCoinbaseRewards returns, per address, the coinbase rewards that cannot be spent in the block at the
+// given height. A coinbase of height h can be spent from height h + maturity on, the block's own coinbase
+// included. chain holds at least the blocks below height, block is the block at height itself if known.
+func ImmatureCoinbaseRewards(chain []Block, block *Block, height int64, maturity int64) func(address string) primitives.Amount {
+	immature := map[string]primitives.Amount{}
+	add := func(block *Block) {
+		if len(block.Transactions) > 0 && block.Transactions[0].Kind == primitives.TransactionKindCoinbase {
+			immature[block.Transactions[0].ToAddress] += block.Transactions[0].Amount
+		}
+	}
+
+	if maturity > 0 {
+		for index := max(0, height-maturity+1); index < height && index < int64(len(chain)); index++ {
+			add(&chain[index])
+		}
+
+		if block != nil {
+			add(block)
+		}
+	}
+
+	return func(address string) primitives.Amount {
+		return immature[address]
+	}
+}
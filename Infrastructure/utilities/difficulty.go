@@ -0,0 +1,114 @@
+package utilities
+
+import (
+	settings "bitshare-chain/infrastructure/settings"
+	hex "encoding/hex"
+	big "math/big"
+	time "time"
+)
+
+// Block times outside of a quarter and four times the expected window are clamped,
+// so a single window can never move the target by more than a factor of four.
+const maxRetargetFactor = 4
+
+// DifficultyParams are the consensus rules for the proof of work target.
+type DifficultyParams struct {
+	// InitialBits is the compact target of the genesis block and of every block of the first window.
+	InitialBits uint32
+	// MaxTarget is the easiest target the chain can retarget to.
+	MaxTarget *big.Int
+	// TargetBlockTime is the interval the retarget steers the chain towards.
+	TargetBlockTime time.Duration
+	// RetargetWindow is the number of blocks between retargets and the number of block times averaged.
+	RetargetWindow int64
+}
+
+func NewDifficultyParams(spec *settings.GenesisSpec) DifficultyParams {
+	initialTarget := new(big.Int).Lsh(big.NewInt(1), uint(256-4*spec.Difficulty))
+	initialTarget.Sub(initialTarget, big.NewInt(1))
+	initialBits := TargetToCompact(initialTarget)
+
+	return DifficultyParams{
+		InitialBits:     initialBits,
+		MaxTarget:       CompactToTarget(initialBits),
+		TargetBlockTime: time.Duration(spec.TargetBlockTime) * time.Second,
+		RetargetWindow:  spec.RetargetWindow,
+	}
+}
+
+// NextBlockBits returns the compact target a block on top of parent has to carry. The target only changes
+// on window boundaries, where it is scaled by the average block time of the last window compared to the
+// target block time. windowStart is the header RetargetWindow blocks below parent, or nil when the chain
+// is not that long yet.
+func (params DifficultyParams) NextBlockBits(parent *BlockHeader, windowStart *BlockHeader) uint32 {
+	height := parent.Height + 1
+	if height%params.RetargetWindow != 0 || windowStart == nil {
+		return parent.Bits
+	}
+
+	expected := params.TargetBlockTime.Milliseconds() * params.RetargetWindow
+	actual := parent.TimeStamp.Sub(windowStart.TimeStamp).Milliseconds()
+	if actual < expected/maxRetargetFactor {
+		actual = expected / maxRetargetFactor
+	}
+	if actual > expected*maxRetargetFactor {
+		actual = expected * maxRetargetFactor
+	}
+
+	target := CompactToTarget(parent.Bits)
+	target.Mul(target, big.NewInt(actual))
+	target.Div(target, big.NewInt(expected))
+
+	if target.Cmp(params.MaxTarget) > 0 {
+		target.Set(params.MaxTarget)
+	}
+	if target.Sign() == 0 {
+		target.SetInt64(1)
+	}
+
+	return TargetToCompact(target)
+}
+
+// CompactToTarget expands the compact representation used in block headers: the highest byte is the
+// length of the target in bytes and the lower three bytes are its most significant bytes.
+func CompactToTarget(bits uint32) *big.Int {
+	size := bits >> 24
+	mantissa := int64(bits & 0x007fffff)
+
+	target := big.NewInt(mantissa)
+	if size <= 3 {
+		return target.Rsh(target, uint(8*(3-size)))
+	}
+
+	return target.Lsh(target, uint(8*(size-3)))
+}
+
+// TargetToCompact is the inverse of CompactToTarget, precision beyond the three most significant bytes is dropped.
+func TargetToCompact(target *big.Int) uint32 {
+	size := uint32(len(target.Bytes()))
+
+	var mantissa uint32
+	if size <= 3 {
+		mantissa = uint32(target.Uint64()) << (8 * (3 - size))
+	} else {
+		mantissa = uint32(new(big.Int).Rsh(target, uint(8*(size-3))).Uint64())
+	}
+
+	// The top bit of the mantissa is a sign bit, move the mantissa one byte down instead of setting it.
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		size++
+	}
+
+	return size<<24 | mantissa
+}
+
+// hashMeetsTarget reports whether the hex encoded hash, read as a big endian number, is at most the target.
+func hashMeetsTarget(hash string, target *big.Int) bool {
+	hashBytes, err := hex.DecodeString(hash)
+	if err != nil || len(hashBytes) == 0 {
+		return false
+	}
+
+	return new(big.Int).SetBytes(hashBytes).Cmp(target) <= 0
+}
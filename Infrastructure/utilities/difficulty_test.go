@@ -0,0 +1,142 @@
+package utilities
+
+import (
+	settings "bitshare-chain/infrastructure/settings"
+	big "math/big"
+	testing "testing"
+	time "time"
+)
+
+func hexTarget(t *testing.T, value string) *big.Int {
+	t.Helper()
+
+	target, ok := new(big.Int).SetString(value, 16)
+	if !ok {
+		t.Fatalf("invalid target %s", value)
+	}
+	return target
+}
+
+func TestCompactTargetRoundTrip(t *testing.T) {
+	tests := []struct {
+		bits   uint32
+		target string
+	}{
+		{0x1d00ffff, "ffff0000000000000000000000000000000000000000000000000000"},
+		{0x1b0404cb, "404cb000000000000000000000000000000000000000000000000"},
+		{0x1f00ffff, "ffff00000000000000000000000000000000000000000000000000000000"},
+		{0x03123456, "123456"},
+		{0x02123400, "1234"},
+		{0x01120000, "12"},
+		{0x02008000, "80"},
+		{0x05009234, "92340000"},
+		{0x01000001, "0"},
+	}
+
+	for _, test := range tests {
+		target := CompactToTarget(test.bits)
+		if expected := hexTarget(t, test.target); target.Cmp(expected) != 0 {
+			t.Fatalf("%08x: got %x, want %x", test.bits, target, expected)
+		}
+		if test.target == "0" {
+			continue
+		}
+		if bits := TargetToCompact(target); bits != test.bits {
+			t.Fatalf("%x: got %08x, want %08x", target, bits, test.bits)
+		}
+	}
+}
+
+func TestTargetToCompactNormalizesTheSignBit(t *testing.T) {
+	tests := []struct {
+		target string
+		bits   uint32
+	}{
+		// A mantissa with its top bit set would read as negative, so it moves one byte down.
+		{"80", 0x02008000},
+		{"ff", 0x0200ff00},
+		{"800000", 0x04008000},
+		{"ffffff", 0x0400ffff},
+		{"7fffff", 0x037fffff},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 0x1f00ffff},
+	}
+
+	for _, test := range tests {
+		bits := TargetToCompact(hexTarget(t, test.target))
+		if bits != test.bits {
+			t.Fatalf("%s: got %08x, want %08x", test.target, bits, test.bits)
+		}
+		if bits&0x00800000 != 0 {
+			t.Fatalf("%s: %08x has the sign bit set", test.target, bits)
+		}
+		if CompactToTarget(bits).Cmp(hexTarget(t, test.target)) > 0 {
+			t.Fatalf("%s: %08x expands above the target", test.target, bits)
+		}
+	}
+}
+
+func TestNewDifficultyParams(t *testing.T) {
+	params := NewDifficultyParams(&settings.GenesisSpec{Difficulty: 4, TargetBlockTime: 30, RetargetWindow: 20})
+
+	if params.InitialBits != 0x1f00ffff {
+		t.Fatalf("got bits %08x, want %08x", params.InitialBits, 0x1f00ffff)
+	}
+	if params.MaxTarget.Cmp(CompactToTarget(params.InitialBits)) != 0 {
+		t.Fatalf("got max target %x, want the initial target", params.MaxTarget)
+	}
+	if params.TargetBlockTime != 30*time.Second || params.RetargetWindow != 20 {
+		t.Fatalf("got %v and %d, want 30s and 20", params.TargetBlockTime, params.RetargetWindow)
+	}
+}
+
+func TestNextBlockBits(t *testing.T) {
+	const bits = 0x1d00ffff
+	params := DifficultyParams{
+		InitialBits:     0x1f00ffff,
+		MaxTarget:       CompactToTarget(0x1f00ffff),
+		TargetBlockTime: 10 * time.Second,
+		RetargetWindow:  10,
+	}
+	start := time.Unix(1_700_000_000, 0)
+	target := CompactToTarget(bits)
+	scaled := func(numerator, denominator int64) uint32 {
+		scaledTarget := new(big.Int).Mul(target, big.NewInt(numerator))
+		return TargetToCompact(scaledTarget.Div(scaledTarget, big.NewInt(denominator)))
+	}
+
+	tests := []struct {
+		name         string
+		parentHeight int64
+		parentBits   uint32
+		window       time.Duration
+		noWindow     bool
+		expected     uint32
+	}{
+		{"inside the window", 8, bits, time.Second, false, bits},
+		{"after a boundary", 10, bits, time.Second, false, bits},
+		{"short chain", 9, bits, time.Second, true, bits},
+		{"on time", 9, bits, 100 * time.Second, false, bits},
+		{"twice as fast", 19, bits, 50 * time.Second, false, scaled(1, 2)},
+		{"twice as slow", 19, bits, 200 * time.Second, false, scaled(2, 1)},
+		{"four times as fast", 9, bits, 25 * time.Second, false, scaled(1, 4)},
+		{"clamped fast", 9, bits, time.Second, false, scaled(1, 4)},
+		{"clamped backwards", 9, bits, -time.Hour, false, scaled(1, 4)},
+		{"four times as slow", 9, bits, 400 * time.Second, false, scaled(4, 1)},
+		{"clamped slow", 9, bits, 24 * time.Hour, false, scaled(4, 1)},
+		{"capped at the max target", 9, 0x1e7fffff, 400 * time.Second, false, params.InitialBits},
+		{"at the max target", 9, params.InitialBits, 200 * time.Second, false, params.InitialBits},
+		{"never zero", 9, 0x01010000, time.Second, false, 0x01010000},
+	}
+
+	for _, test := range tests {
+		parent := &BlockHeader{Height: test.parentHeight, Bits: test.parentBits, TimeStamp: start.Add(test.window)}
+		windowStart := &BlockHeader{Height: test.parentHeight - params.RetargetWindow, TimeStamp: start}
+		if test.noWindow {
+			windowStart = nil
+		}
+
+		if nextBits := params.NextBlockBits(parent, windowStart); nextBits != test.expected {
+			t.Fatalf("%s: got %08x, want %08x", test.name, nextBits, test.expected)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	errors "errors"
+	fmt "fmt"
+	math "math"
+)
+
+// EmissionSchedule decides how many new coins the coinbase of every block may create. The reward starts at
+// InitialReward and halves every HalvingInterval blocks. Once MaxSupply coins exist, counting the genesis
+// allocations, no more are created. A zero HalvingInterval or MaxSupply disables that rule.
+type EmissionSchedule struct {
+	InitialReward   primitives.Amount
+	HalvingInterval int64
+	MaxSupply       primitives.Amount
+	GenesisSupply   primitives.Amount
+}
+
+// SupplyInfo is the emission state of the chain at its tip.
+type SupplyInfo struct {
+	Height            int64
+	IssuedSupply      primitives.Amount
+	MaxSupply         primitives.Amount
+	CurrentReward     primitives.Amount
+	NextHalvingHeight int64
+}
+
+func NewEmissionSchedule(spec *settings.GenesisSpec, genesisBlock *Block) (EmissionSchedule, error) {
+	initialReward, err := primitives.ParseAmount(spec.MiningReward)
+	if err != nil {
+		return EmissionSchedule{}, fmt.Errorf("invalid genesis mining reward: %v", err)
+	}
+
+	schedule := EmissionSchedule{
+		InitialReward:   initialReward,
+		HalvingInterval: spec.HalvingInterval,
+	}
+
+	if spec.MaxSupply != "" {
+		if schedule.MaxSupply, err = primitives.ParseAmount(spec.MaxSupply); err != nil || !schedule.MaxSupply.IsPositive() {
+			return EmissionSchedule{}, fmt.Errorf("invalid genesis max supply %q", spec.MaxSupply)
+		}
+	}
+
+	for _, transaction := range genesisBlock.Transactions {
+		if schedule.GenesisSupply, err = schedule.GenesisSupply.Add(transaction.Amount); err != nil {
+			return EmissionSchedule{}, err
+		}
+	}
+
+	if schedule.MaxSupply != 0 && schedule.GenesisSupply > schedule.MaxSupply {
+		return EmissionSchedule{}, errors.New("genesis allocations exceed the max supply")
+	}
+
+	return schedule, nil
+}
+
+// BlockReward is what the coinbase of the block at the given height may create on top of the fees.
+func (schedule EmissionSchedule) BlockReward(height int64) primitives.Amount {
+	if height <= 0 {
+		return 0
+	}
+
+	return schedule.IssuedSupply(height) - schedule.IssuedSupply(height-1)
+}
+
+// IssuedSupply is the number of coins that exist once the block at the given height is mined.
+func (schedule EmissionSchedule) IssuedSupply(height int64) primitives.Amount {
+	supply := saturatingAdd(schedule.GenesisSupply, schedule.scheduledSupply(height))
+	if schedule.MaxSupply != 0 && supply > schedule.MaxSupply {
+		return schedule.MaxSupply
+	}
+
+	return supply
+}
+
+// NextHalvingHeight is the first height after the given one with a halved reward, or -1 without halvings.
+func (schedule EmissionSchedule) NextHalvingHeight(height int64) int64 {
+	if schedule.HalvingInterval <= 0 {
+		return -1
+	}
+
+	return (height/schedule.HalvingInterval + 1) * schedule.HalvingInterval
+}
+
+// scheduledReward is the reward of a height before the max supply is applied.
+func (schedule EmissionSchedule) scheduledReward(height int64) primitives.Amount {
+	if height <= 0 {
+		return 0
+	}
+
+	if schedule.HalvingInterval <= 0 {
+		return schedule.InitialReward
+	}
+
+	halvings := height / schedule.HalvingInterval
+	if halvings >= 63 {
+		return 0
+	}
+
+	return schedule.InitialReward >> halvings
+}
+
+// scheduledSupply sums the scheduled rewards of heights 1 to height, one reward era at a time.
+func (schedule EmissionSchedule) scheduledSupply(height int64) primitives.Amount {
+	var supply primitives.Amount
+
+	for eraStart := int64(1); eraStart <= height; {
+		reward := schedule.scheduledReward(eraStart)
+		if reward == 0 {
+			break
+		}
+
+		eraEnd := height
+		if schedule.HalvingInterval > 0 {
+			eraEnd = min(height, (eraStart/schedule.HalvingInterval+1)*schedule.HalvingInterval-1)
+		}
+
+		blocks := eraEnd - eraStart + 1
+		if blocks > math.MaxInt64/int64(reward) {
+			return primitives.Amount(math.MaxInt64)
+		}
+
+		supply = saturatingAdd(supply, reward*primitives.Amount(blocks))
+		eraStart = eraEnd + 1
+	}
+
+	return supply
+}
+
+func saturatingAdd(first primitives.Amount, second primitives.Amount) primitives.Amount {
+	sum, err := first.Add(second)
+	if err != nil {
+		return primitives.Amount(math.MaxInt64)
+	}
+
+	return sum
+}
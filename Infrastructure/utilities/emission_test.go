@@ -0,0 +1,157 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	math "math"
+	testing "testing"
+)
+
+const coin = primitives.AmountUnitsPerCoin
+
+func TestBlockRewardAroundHalvings(t *testing.T) {
+	schedule := EmissionSchedule{InitialReward: 50 * coin, HalvingInterval: 10, GenesisSupply: 100 * coin}
+
+	tests := map[int64]primitives.Amount{
+		-1: 0,
+		0:  0,
+		1:  50 * coin,
+		9:  50 * coin,
+		10: 25 * coin,
+		11: 25 * coin,
+		19: 25 * coin,
+		20: coin * 25 / 2,
+		21: coin * 25 / 2,
+	}
+
+	for height, expected := range tests {
+		if reward := schedule.BlockReward(height); reward != expected {
+			t.Fatalf("height %d: got %s, want %s", height, reward, expected)
+		}
+	}
+}
+
+func TestBlockRewardReachesZero(t *testing.T) {
+	schedule := EmissionSchedule{InitialReward: 8, HalvingInterval: 2}
+
+	expected := []primitives.Amount{0, 8, 4, 4, 2, 2, 1, 1, 0, 0}
+	for height, reward := range expected {
+		if got := schedule.BlockReward(int64(height)); got != reward {
+			t.Fatalf("height %d: got %d, want %d", height, got, reward)
+		}
+	}
+
+	if supply := schedule.IssuedSupply(math.MaxInt64); supply != 22 {
+		t.Fatalf("got a final supply of %d, want 22", supply)
+	}
+	if reward := schedule.BlockReward(math.MaxInt64); reward != 0 {
+		t.Fatalf("got a reward of %d at the last height, want 0", reward)
+	}
+}
+
+func TestIssuedSupplyStopsAtMaxSupply(t *testing.T) {
+	schedule := EmissionSchedule{InitialReward: 50 * coin, MaxSupply: 220 * coin, GenesisSupply: 100 * coin}
+
+	expected := []primitives.Amount{0, 50 * coin, 50 * coin, 20 * coin, 0, 0}
+	for height, reward := range expected {
+		if got := schedule.BlockReward(int64(height)); got != reward {
+			t.Fatalf("height %d: got %s, want %s", height, got, reward)
+		}
+	}
+
+	unlimited := EmissionSchedule{InitialReward: math.MaxInt64 / 4, HalvingInterval: 1_000_000, MaxSupply: math.MaxInt64 - 1}
+	for _, current := range []EmissionSchedule{schedule, unlimited} {
+		supply := current.GenesisSupply
+		for _, height := range []int64{1, 2, 3, 4, 10, 999_999, 1_000_000, 1_000_001, math.MaxInt64 - 1, math.MaxInt64} {
+			issued := current.IssuedSupply(height)
+			if issued > current.MaxSupply || issued < supply {
+				t.Fatalf("height %d: got %s after %s, want at most %s", height, issued, supply, current.MaxSupply)
+			}
+			supply = issued
+		}
+	}
+}
+
+func TestIssuedSupplyAddsUpTheRewards(t *testing.T) {
+	schedule := EmissionSchedule{InitialReward: 50 * coin, HalvingInterval: 7, MaxSupply: 500 * coin, GenesisSupply: 100 * coin}
+
+	supply := schedule.GenesisSupply
+	for height := int64(0); height < 100; height++ {
+		supply += schedule.BlockReward(height)
+		if issued := schedule.IssuedSupply(height); issued != supply {
+			t.Fatalf("height %d: got %s, want %s", height, issued, supply)
+		}
+	}
+}
+
+func TestNextHalvingHeight(t *testing.T) {
+	schedule := EmissionSchedule{InitialReward: 50 * coin, HalvingInterval: 10}
+
+	tests := map[int64]int64{0: 10, 1: 10, 9: 10, 10: 20, 11: 20, 19: 20, 20: 30}
+	for height, expected := range tests {
+		if next := schedule.NextHalvingHeight(height); next != expected {
+			t.Fatalf("height %d: got %d, want %d", height, next, expected)
+		}
+	}
+
+	if next := (EmissionSchedule{InitialReward: 50 * coin}).NextHalvingHeight(10); next != -1 {
+		t.Fatalf("got %d without halvings, want -1", next)
+	}
+}
+
+func TestNewEmissionScheduleRejectsAllocationsAboveMaxSupply(t *testing.T) {
+	funded := newTestAccount(t)
+	spec := newTestGenesisSpec(funded)
+	spec.MaxSupply = "99"
+
+	genesisBlock, err := createGenesisBlock(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEmissionSchedule(spec, &genesisBlock); err == nil {
+		t.Fatal("allocations above the max supply were accepted")
+	}
+
+	spec.MaxSupply = "100"
+	schedule, err := NewEmissionSchedule(spec, &genesisBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.GenesisSupply != 100*coin || schedule.BlockReward(1) != 0 {
+		t.Fatalf("got a genesis supply of %s and a first reward of %s, want 100 and 0", schedule.GenesisSupply, schedule.BlockReward(1))
+	}
+}
+
+func TestGetSupplyCountsCoinbaseAndAllocationsWithoutFees(t *testing.T) {
+	sender, recipient, miner := newTestAccount(t), newTestAccount(t), newTestAccount(t)
+	spec := newTestGenesisSpec(sender)
+	spec.HalvingInterval = 2
+
+	blockchain, err := NewBlockchain(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		if err := blockchain.SubmitBlock(mineBlock(t, blockchain, miner, transfer(t, sender, recipient.address, 10, nonce))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	supply, err := blockchain.GetSupply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The coinbases collect the transfer fees on top of the 50, 25 and 25 coin rewards, fees are not new coins.
+	expected := SupplyInfo{
+		Height:            3,
+		IssuedSupply:      200 * coin,
+		CurrentReward:     coin * 25 / 2,
+		NextHalvingHeight: 6,
+	}
+	if supply != expected {
+		t.Fatalf("got %+v, want %+v", supply, expected)
+	}
+	if supply.IssuedSupply != blockchain.Emission.IssuedSupply(supply.Height) {
+		t.Fatalf("got %s, the schedule issued %s", supply.IssuedSupply, blockchain.Emission.IssuedSupply(supply.Height))
+	}
+}
@@ -0,0 +1,304 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	errors "errors"
+	fmt "fmt"
+	time "time"
+)
+
+// MaxReorgDepth is the number of canonical blocks a reorganization may disconnect. Branches that fork off
+// deeper are kept in the tree but never become canonical.
+const MaxReorgDepth int64 = 100
+
+var ErrReorgTooDeep = errors.New("reorganization exceeds the maximum depth")
+
+// ReorgEvent describes a switch of the canonical chain to a branch with more cumulative work.
+type ReorgEvent struct {
+	ForkHeight   int64
+	ForkHash     string
+	OldTip       string
+	NewTip       string
+	Disconnected []Block
+	Connected    []Block
+	// Orphaned are the transactions of the disconnected blocks that the new branch does not contain.
+	Orphaned []primitives.BlockTransaction
+}
+
+// SubscribeReorgs registers a handler that is called after every reorganization.
+func (blockChain *Blockchain) SubscribeReorgs(handler func(event ReorgEvent)) {
+	blockChain.mutex.Lock()
+	defer blockChain.mutex.Unlock()
+
+	blockChain.reorgSubscribers = append(blockChain.reorgSubscribers, handler)
+}
+
+// AddBlock accepts a block from another node. The block ends up on a side chain unless its branch has more
+// cumulative work than the canonical chain, in which case it becomes the new tip, reorganizing if needed.
+func (blockChain *Blockchain) AddBlock(block Block) error {
+	blockChain.mutex.Lock()
+	connected, event, err := blockChain.addBlock(block)
+	blockChain.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	blockChain.notify(connected, event)
+	return nil
+}
+
+// addBlock returns the block when it extended the canonical chain, or the reorg event when it caused one.
+func (blockChain *Blockchain) addBlock(block Block) (*Block, *ReorgEvent, error) {
+	if _, ok := blockChain.tree.Get(block.Hash); ok {
+		return nil, nil, ErrBlockAlreadyKnown
+	}
+
+	parent, ok := blockChain.tree.Get(block.Header.PreviousHash)
+	if !ok {
+		return nil, nil, ErrUnknownParent
+	}
+
+	if err := blockChain.validateBlock(&block, parent); err != nil {
+		return nil, nil, err
+	}
+
+	node, err := blockChain.tree.Add(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tip := blockChain.tipNode()
+	if node.CumulativeWork.Cmp(tip.CumulativeWork) <= 0 {
+		return nil, nil, nil
+	}
+
+	if parent != tip {
+		event, err := blockChain.reorganize(node)
+		return nil, event, err
+	}
+
+	if err := blockChain.connectBlock(&node.Block); err != nil {
+		blockChain.tree.Remove(block.Hash)
+		return nil, nil, err
+	}
+
+	return &node.Block, nil, nil
+}
+
+// notify calls the subscribers without holding the chain lock, so they are free to read from the chain.
+func (blockChain *Blockchain) notify(connected *Block, event *ReorgEvent) {
+	blockChain.mutex.RLock()
+	blockSubscribers := blockChain.blockSubscribers
+	reorgSubscribers := blockChain.reorgSubscribers
+	blockChain.mutex.RUnlock()
+
+	if connected != nil {
+		for _, handler := range blockSubscribers {
+			handler(*connected)
+		}
+	}
+
+	if event != nil {
+		for _, handler := range reorgSubscribers {
+			handler(*event)
+		}
+	}
+}
+
+// validateBlock checks everything about a block that does not depend on account states.
+func (blockChain *Blockchain) validateBlock(block *Block, parent *BlockTreeNode) error {
+	if block.Hash != block.CalculateHash() {
+		return fmt.Errorf("%w: hash does not match the header", ErrInvalidBlockHeader)
+	}
+
+	if err := ValidateBlockHeader(&block.Header, &parent.Block.Header, time.Now()); err != nil {
+		return err
+	}
+
+	if block.Header.Bits != blockChain.nextBlockBits(parent) {
+		return fmt.Errorf("%w: target does not follow the difficulty rules", ErrInvalidBlockHeader)
+	}
+
+	if !block.HasValidMerkleRoot() {
+		return errors.New("merkle root does not match the transactions")
+	}
+
+	if err := ValidateCoinbase(block, blockChain.Emission.BlockReward(block.Header.Height)); err != nil {
+		return err
+	}
+
+	if !block.HasValidTransactions() {
+		return errors.New("block contains invalid transactions")
+	}
+
+	return nil
+}
+
+// reorganize switches the canonical chain to the branch ending in node. When a block of the new branch turns
+// out to be invalid the branch is dropped from the tree and the old chain is restored.
+func (blockChain *Blockchain) reorganize(node *BlockTreeNode) (*ReorgEvent, error) {
+	tip := blockChain.tipNode()
+	fork := FindForkPoint(tip, node)
+
+	if depth := tip.Height() - fork.Height(); depth > MaxReorgDepth {
+		return nil, fmt.Errorf("%w: %d blocks, at most %d", ErrReorgTooDeep, depth, MaxReorgDepth)
+	}
+
+	branch := []Block{}
+	for current := node; current != fork; current = current.Parent {
+		branch = append([]Block{current.Block}, branch...)
+	}
+
+	disconnected := append([]Block{}, blockChain.Chain[fork.Height()+1:]...)
+	touched := []string{}
+
+	for int64(len(blockChain.Chain)) > fork.Height()+1 {
+		addresses, err := blockChain.disconnectTip()
+		if err != nil {
+			return nil, err
+		}
+		touched = append(touched, addresses...)
+	}
+
+	for index := range branch {
+		addresses, err := blockChain.connectTip(&branch[index])
+		if err == nil {
+			touched = append(touched, addresses...)
+			continue
+		}
+
+		blockChain.tree.Remove(branch[index].Hash)
+		if restoreErr := blockChain.restoreBranch(fork.Height(), disconnected); restoreErr != nil {
+			return nil, fmt.Errorf("failed to restore the chain after an invalid branch: %v", restoreErr)
+		}
+		return nil, err
+	}
+
+	// The in memory chain already switched. When the store cannot switch as well, both go back to the old
+	// branch so that they keep agreeing on the tip.
+	if err := blockChain.storeBranch(fork.Height(), branch); err != nil {
+		if restoreErr := blockChain.restoreBranch(fork.Height(), disconnected); restoreErr != nil {
+			return nil, fmt.Errorf("failed to restore the chain after a store failure: %v", restoreErr)
+		}
+
+		if restoreErr := blockChain.storeBranch(fork.Height(), disconnected); restoreErr != nil {
+			return nil, fmt.Errorf("failed to restore the stored chain after %v: %v", err, restoreErr)
+		}
+		return nil, err
+	}
+
+	if err := blockChain.saveAccountStates(touched); err != nil {
+		return nil, err
+	}
+
+	event := &ReorgEvent{
+		ForkHeight:   fork.Height(),
+		ForkHash:     fork.Block.Hash,
+		OldTip:       tip.Block.Hash,
+		NewTip:       node.Block.Hash,
+		Disconnected: disconnected,
+		Connected:    branch,
+		Orphaned:     orphanedTransactions(disconnected, branch),
+	}
+
+	return event, nil
+}
+
+// restoreBranch rewinds the chain to the given height and connects blocks on top of it again.
+func (blockChain *Blockchain) restoreBranch(height int64, blocks []Block) error {
+	for int64(len(blockChain.Chain)) > height+1 {
+		if _, err := blockChain.disconnectTip(); err != nil {
+			return err
+		}
+	}
+
+	for index := range blocks {
+		if _, err := blockChain.connectTip(&blocks[index]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeBranch replaces the stored blocks above the given height with the branch. A store keeps one block per
+// height, so the branch can only be written once the old blocks are gone. A crash in between leaves the stored
+// chain at the fork point, which the next start loads like any other shorter chain.
+func (blockChain *Blockchain) storeBranch(height int64, branch []Block) error {
+	if blockChain.store == nil {
+		return nil
+	}
+
+	if err := blockChain.store.RemoveBlocksAbove(height); err != nil {
+		return err
+	}
+
+	for _, block := range branch {
+		if err := blockChain.store.AppendBlock(block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// connectTip applies a block on top of the in memory chain and returns the addresses it touched.
+func (blockChain *Blockchain) connectTip(block *Block) ([]string, error) {
+	height := int64(len(blockChain.Chain))
+	immature := ImmatureCoinbaseRewards(blockChain.Chain, block, height, blockChain.CoinbaseMaturity)
+	undo, err := blockChain.accounts.ApplyBlock(block, height, immature)
+	if err != nil {
+		return nil, err
+	}
+
+	blockChain.Chain = append(blockChain.Chain, *block)
+	blockChain.undos = append(blockChain.undos, undo)
+	return undo.Addresses(), nil
+}
+
+// disconnectTip reverts the last block of the in memory chain and returns the addresses it touched.
+func (blockChain *Blockchain) disconnectTip() ([]string, error) {
+	height := int64(len(blockChain.Chain) - 1)
+	if height == 0 {
+		return nil, errors.New("cannot disconnect the genesis block")
+	}
+
+	updated, removed, err := blockChain.accounts.RevertBlock(&blockChain.Chain[height], blockChain.undos[height])
+	if err != nil {
+		return nil, err
+	}
+
+	blockChain.Chain = blockChain.Chain[:height]
+	blockChain.undos = blockChain.undos[:height]
+	return append(updated, removed...), nil
+}
+
+func (blockChain *Blockchain) tipNode() *BlockTreeNode {
+	node, _ := blockChain.tree.Get(getLatestBlockHash(blockChain.Chain))
+	return node
+}
+
+// orphanedTransactions returns the transfers of the disconnected blocks that are not part of the connected ones.
+// Coinbase transactions of disconnected blocks are lost with their block.
+func orphanedTransactions(disconnected []Block, connected []Block) []primitives.BlockTransaction {
+	included := map[string]bool{}
+	for _, block := range connected {
+		for index := range block.Transactions {
+			included[block.Transactions[index].TransactionId()] = true
+		}
+	}
+
+	orphaned := []primitives.BlockTransaction{}
+	for _, block := range disconnected {
+		for index := range block.Transactions {
+			transaction := block.Transactions[index]
+			if transaction.Kind != primitives.TransactionKindTransfer || included[transaction.TransactionId()] {
+				continue
+			}
+			orphaned = append(orphaned, transaction)
+		}
+	}
+
+	return orphaned
+}
@@ -0,0 +1,50 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	json "encoding/json"
+	fmt "fmt"
+)
+
+// createGenesisBlock builds block 0 from the spec alone, so every node derives the same hash.
+// The genesis block has no parent, its PreviousHash carries the spec hash instead. That way the
+// chain id, difficulty and reward are committed to by the genesis hash next to the allocations.
+func createGenesisBlock(spec *settings.GenesisSpec) (Block, error) {
+	transactions := make([]primitives.BlockTransaction, 0, len(spec.Allocations))
+	for _, allocation := range spec.Allocations {
+		if err := primitives.ValidateAddress(allocation.Address); err != nil {
+			return Block{}, fmt.Errorf("genesis allocation: %v", err)
+		}
+
+		amount, err := primitives.ParseAmount(allocation.Amount)
+		if err != nil || !amount.IsPositive() {
+			return Block{}, fmt.Errorf("invalid genesis allocation amount %q for %s", allocation.Amount, allocation.Address)
+		}
+
+		transactions = append(transactions, primitives.BlockTransaction{Kind: primitives.TransactionKindAllocation, ToAddress: allocation.Address, Amount: amount})
+	}
+
+	specHash, err := hashGenesisSpec(spec)
+	if err != nil {
+		return Block{}, err
+	}
+
+	genesisBlock := NewBlock(0, spec.TimeStamp.UTC(), transactions, specHash, "", NewDifficultyParams(spec).InitialBits)
+	return *genesisBlock, nil
+}
+
+func hashGenesisSpec(spec *settings.GenesisSpec) (string, error) {
+	normalizedSpec := *spec
+	normalizedSpec.TimeStamp = spec.TimeStamp.UTC()
+
+	specBytes, err := json.Marshal(normalizedSpec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode genesis spec: %v", err)
+	}
+
+	hash := sha256.Sum256(specBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,132 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	bytes "bytes"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	errors "errors"
+)
+
+// Leaves and inner nodes are hashed with different prefixes, so an inner node can never be
+// passed off as a transaction. A node without a sibling is promoted to the next level as is,
+// instead of being paired with itself.
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+var ErrInvalidMerkleProof = errors.New("merkle proof does not match the merkle root")
+
+// MerkleProofStep is one sibling on the path from a transaction to the merkle root.
+type MerkleProofStep struct {
+	Hash   []byte
+	IsLeft bool
+}
+
+// MerkleProof proves that a transaction is included in a block without shipping the block.
+type MerkleProof struct {
+	TransactionId string
+	Index         int
+	Steps         []MerkleProofStep
+}
+
+// ComputeMerkleRoot returns the hex encoded merkle root over the transaction ids.
+// A block without transactions has an all zero root.
+func ComputeMerkleRoot(transactions []primitives.BlockTransaction) string {
+	level := merkleLeaves(transactions)
+	if len(level) == 0 {
+		return hex.EncodeToString(make([]byte, sha256.Size))
+	}
+
+	for len(level) > 1 {
+		level = nextMerkleLevel(level)
+	}
+
+	return hex.EncodeToString(level[0])
+}
+
+// BuildMerkleProof returns the inclusion proof for the transaction at the given index.
+func BuildMerkleProof(transactions []primitives.BlockTransaction, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(transactions) {
+		return nil, errors.New("transaction index out of range")
+	}
+
+	proof := &MerkleProof{
+		TransactionId: transactions[index].TransactionId(),
+		Index:         index,
+		Steps:         []MerkleProofStep{},
+	}
+
+	level := merkleLeaves(transactions)
+	position := index
+	for len(level) > 1 {
+		if position%2 == 1 {
+			proof.Steps = append(proof.Steps, MerkleProofStep{Hash: level[position-1], IsLeft: true})
+		} else if position+1 < len(level) {
+			proof.Steps = append(proof.Steps, MerkleProofStep{Hash: level[position+1], IsLeft: false})
+		}
+
+		level = nextMerkleLevel(level)
+		position /= 2
+	}
+
+	return proof, nil
+}
+
+// VerifyMerkleProof checks a proof against the merkle root of a block header.
+func VerifyMerkleProof(header *BlockHeader, proof *MerkleProof) error {
+	expectedRoot, err := hex.DecodeString(header.MerkleRoot)
+	if err != nil {
+		return err
+	}
+
+	transactionId, err := hex.DecodeString(proof.TransactionId)
+	if err != nil {
+		return err
+	}
+
+	current := merkleHash(merkleLeafPrefix, transactionId)
+	for _, step := range proof.Steps {
+		if step.IsLeft {
+			current = merkleHash(merkleNodePrefix, step.Hash, current)
+		} else {
+			current = merkleHash(merkleNodePrefix, current, step.Hash)
+		}
+	}
+
+	if !bytes.Equal(current, expectedRoot) {
+		return ErrInvalidMerkleProof
+	}
+
+	return nil
+}
+
+func merkleLeaves(transactions []primitives.BlockTransaction) [][]byte {
+	leaves := make([][]byte, 0, len(transactions))
+	for index := range transactions {
+		leaves = append(leaves, merkleHash(merkleLeafPrefix, transactions[index].Id()))
+	}
+	return leaves
+}
+
+func nextMerkleLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for index := 0; index < len(level); index += 2 {
+		if index+1 == len(level) {
+			next = append(next, level[index])
+			continue
+		}
+		next = append(next, merkleHash(merkleNodePrefix, level[index], level[index+1]))
+	}
+	return next
+}
+
+func merkleHash(prefix byte, parts ...[]byte) []byte {
+	hash := sha256.New()
+	hash.Write([]byte{prefix})
+	for _, part := range parts {
+		hash.Write(part)
+	}
+	return hash.Sum(nil)
+}
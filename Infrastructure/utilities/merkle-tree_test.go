@@ -0,0 +1,142 @@
+package utilities
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	hex "encoding/hex"
+	errors "errors"
+	testing "testing"
+)
+
+func merkleTestTransactions(count int) []primitives.BlockTransaction {
+	transactions := make([]primitives.BlockTransaction, 0, count)
+	for nonce := 0; nonce < count; nonce++ {
+		transactions = append(transactions, *primitives.NewBlockTransaction("sender", "recipient", primitives.AmountUnitsPerCoin, 1000, uint64(nonce)))
+	}
+	return transactions
+}
+
+func merkleTestHeader(transactions []primitives.BlockTransaction) *BlockHeader {
+	return &BlockHeader{MerkleRoot: ComputeMerkleRoot(transactions)}
+}
+
+func TestMerkleProofsVerify(t *testing.T) {
+	// Proof lengths follow from promoting the last node of an odd level instead of pairing it with itself.
+	tests := []struct {
+		count int
+		steps []int
+	}{
+		{1, []int{0}},
+		{2, []int{1, 1}},
+		{3, []int{2, 2, 1}},
+		{5, []int{3, 3, 3, 3, 1}},
+		{7, []int{3, 3, 3, 3, 3, 3, 2}},
+		{8, []int{3, 3, 3, 3, 3, 3, 3, 3}},
+	}
+
+	for _, test := range tests {
+		transactions := merkleTestTransactions(test.count)
+		header := merkleTestHeader(transactions)
+
+		for index := range transactions {
+			proof, err := BuildMerkleProof(transactions, index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if proof.TransactionId != transactions[index].TransactionId() || proof.Index != index {
+				t.Fatalf("%d of %d: the proof is for %s at %d", index, test.count, proof.TransactionId, proof.Index)
+			}
+			if len(proof.Steps) != test.steps[index] {
+				t.Fatalf("%d of %d: got %d steps, want %d", index, test.count, len(proof.Steps), test.steps[index])
+			}
+			if err := VerifyMerkleProof(header, proof); err != nil {
+				t.Fatalf("%d of %d: %v", index, test.count, err)
+			}
+		}
+	}
+}
+
+func TestMerkleRootOfASingleTransactionIsItsLeafHash(t *testing.T) {
+	transactions := merkleTestTransactions(1)
+
+	expected := hex.EncodeToString(merkleHash(merkleLeafPrefix, transactions[0].Id()))
+	if root := ComputeMerkleRoot(transactions); root != expected {
+		t.Fatalf("got %s, want %s", root, expected)
+	}
+	if root := ComputeMerkleRoot(nil); root != hex.EncodeToString(make([]byte, 32)) {
+		t.Fatalf("got %s for no transactions, want the zero hash", root)
+	}
+}
+
+func TestBuildMerkleProofRejectsIndexesOutOfRange(t *testing.T) {
+	transactions := merkleTestTransactions(3)
+
+	for _, index := range []int{-1, 3, 4} {
+		if proof, err := BuildMerkleProof(transactions, index); err == nil {
+			t.Fatalf("index %d: got %+v, want an error", index, proof)
+		}
+	}
+	if _, err := BuildMerkleProof(nil, 0); err == nil {
+		t.Fatal("a proof was built without transactions")
+	}
+}
+
+func TestVerifyMerkleProofRejectsTamperedProofs(t *testing.T) {
+	transactions := merkleTestTransactions(5)
+	header := merkleTestHeader(transactions)
+	other := merkleTestTransactions(6)[5]
+
+	tamper := map[string]func(proof *MerkleProof, header *BlockHeader){
+		"step hash":    func(proof *MerkleProof, _ *BlockHeader) { proof.Steps[0].Hash[0] ^= 1 },
+		"step side":    func(proof *MerkleProof, _ *BlockHeader) { proof.Steps[1].IsLeft = !proof.Steps[1].IsLeft },
+		"dropped step": func(proof *MerkleProof, _ *BlockHeader) { proof.Steps = proof.Steps[:len(proof.Steps)-1] },
+		"added step": func(proof *MerkleProof, _ *BlockHeader) {
+			proof.Steps = append(proof.Steps, MerkleProofStep{Hash: make([]byte, 32)})
+		},
+		"leaf":            func(proof *MerkleProof, _ *BlockHeader) { proof.TransactionId = other.TransactionId() },
+		"sibling as leaf": func(proof *MerkleProof, _ *BlockHeader) { proof.TransactionId = transactions[3].TransactionId() },
+		"root": func(_ *MerkleProof, header *BlockHeader) {
+			header.MerkleRoot = ComputeMerkleRoot(merkleTestTransactions(4))
+		},
+	}
+
+	for name, apply := range tamper {
+		proof, err := BuildMerkleProof(transactions, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tamperedHeader := *header
+		apply(proof, &tamperedHeader)
+
+		if err := VerifyMerkleProof(&tamperedHeader, proof); !errors.Is(err, ErrInvalidMerkleProof) {
+			t.Fatalf("%s: got %v, want %v", name, err, ErrInvalidMerkleProof)
+		}
+	}
+}
+
+func TestVerifyMerkleProofRejectsInnerNodesAsTransactions(t *testing.T) {
+	transactions := merkleTestTransactions(4)
+	header := merkleTestHeader(transactions)
+	leaves := merkleLeaves(transactions)
+	left := merkleHash(merkleNodePrefix, leaves[0], leaves[1])
+	right := merkleHash(merkleNodePrefix, leaves[2], leaves[3])
+
+	// Without the leaf prefix the 64 bytes of two children, or an inner node with its sibling, would hash to
+	// the root and pass as a transaction that is not in the block.
+	forgeries := map[string]*MerkleProof{
+		"children as id": {TransactionId: hex.EncodeToString(append(append([]byte{}, left...), right...)), Steps: []MerkleProofStep{}},
+		"inner node as id": {
+			TransactionId: hex.EncodeToString(left),
+			Steps:         []MerkleProofStep{{Hash: right, IsLeft: false}},
+		},
+		"leaf hash as id": {
+			TransactionId: hex.EncodeToString(leaves[0]),
+			Steps:         []MerkleProofStep{{Hash: leaves[1], IsLeft: false}, {Hash: right, IsLeft: false}},
+		},
+	}
+
+	for name, proof := range forgeries {
+		if err := VerifyMerkleProof(header, proof); !errors.Is(err, ErrInvalidMerkleProof) {
+			t.Fatalf("%s: got %v, want %v", name, err, ErrInvalidMerkleProof)
+		}
+	}
+}
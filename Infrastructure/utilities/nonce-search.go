@@ -0,0 +1,131 @@
+package utilities
+
+import (
+	bytes "bytes"
+	context "context"
+	sha256 "crypto/sha256"
+	encoding "encoding"
+	binary "encoding/binary"
+	errors "errors"
+	math "math"
+	runtime "runtime"
+	sync "sync"
+)
+
+var ErrNonceSpaceExhausted = errors.New("no nonce satisfies the target")
+
+// headerMidstate is the SHA-256 state after hashing every header field but the nonce. HashingBytes writes the
+// nonce last, so a nonce is tried by restoring the state and hashing its eight bytes only.
+type headerMidstate struct {
+	state  []byte
+	target []byte
+}
+
+func newHeaderMidstate(header *BlockHeader) (*headerMidstate, error) {
+	hashingBytes := header.HashingBytes()
+	digest := sha256.New()
+	digest.Write(hashingBytes[:len(hashingBytes)-8])
+
+	state, err := digest.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	// A target beyond 256 bits is met by every hash.
+	target := bytes.Repeat([]byte{0xff}, sha256.Size)
+	if headerTarget := header.Target(); headerTarget.BitLen() <= 8*sha256.Size {
+		headerTarget.FillBytes(target)
+	}
+
+	return &headerMidstate{state: state, target: target}, nil
+}
+
+// searchNonce splits the nonce space, starting at the header's nonce, into one range per worker and returns a
+// nonce whose header hash meets the target. Fewer than one worker means one per GOMAXPROCS. report is called
+// concurrently by the workers with the number of hashes since their previous report.
+func searchNonce(ctx context.Context, header *BlockHeader, workers int, report func(hashes uint64)) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	midstate, err := newHeaderMidstate(header)
+	if err != nil {
+		return 0, err
+	}
+
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	span := math.MaxUint64 / uint64(workers)
+	results := make(chan uint64, workers)
+	var group sync.WaitGroup
+
+	for worker := 0; worker < workers; worker++ {
+		group.Add(1)
+		go func(start uint64) {
+			defer group.Done()
+
+			if nonce, ok := midstate.search(searchCtx, start, span, report); ok {
+				results <- nonce
+				cancel()
+			}
+		}(header.Nonce + uint64(worker)*span)
+	}
+	group.Wait()
+
+	select {
+	case nonce := <-results:
+		return nonce, nil
+	default:
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return 0, ErrNonceSpaceExhausted
+}
+
+// search tries count nonces from start on until one meets the target or ctx is cancelled.
+func (midstate *headerMidstate) search(ctx context.Context, start uint64, count uint64, report func(hashes uint64)) (uint64, bool) {
+	digest := sha256.New()
+	restorer := digest.(encoding.BinaryUnmarshaler)
+
+	var nonceBytes [8]byte
+	sum := make([]byte, 0, sha256.Size)
+	var hashes, reported uint64
+
+	for offset := uint64(0); offset < count; offset++ {
+		if err := restorer.UnmarshalBinary(midstate.state); err != nil {
+			return 0, false
+		}
+
+		nonce := start + offset
+		binary.BigEndian.PutUint64(nonceBytes[:], nonce)
+		digest.Write(nonceBytes[:])
+		sum = digest.Sum(sum[:0])
+		hashes++
+
+		if bytes.Compare(sum, midstate.target) <= 0 {
+			report(hashes - reported)
+			return nonce, true
+		}
+
+		if hashes%MiningReportInterval != 0 {
+			continue
+		}
+
+		report(hashes - reported)
+		reported = hashes
+
+		if ctx.Err() != nil {
+			return 0, false
+		}
+	}
+
+	report(hashes - reported)
+	return 0, false
+}
@@ -0,0 +1,163 @@
+package utilities
+
+import (
+	context "context"
+	sha256 "crypto/sha256"
+	hex "encoding/hex"
+	fmt "fmt"
+	math "math"
+	big "math/big"
+	runtime "runtime"
+	sync "sync"
+	testing "testing"
+	time "time"
+)
+
+// benchmarkBits is a target one in 4096 hashes meets, so every iteration mines a block in a few thousand hashes.
+var benchmarkBits = TargetToCompact(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 244), big.NewInt(1)))
+
+func benchmarkHeader() BlockHeader {
+	header := goldenBlock().Header
+	header.Bits = benchmarkBits
+	header.Signature = nil
+	return header
+}
+
+func TestSearchNonceMeetsTheTarget(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		header := benchmarkHeader()
+		nonce, err := searchNonce(context.Background(), &header, workers, func(uint64) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header.Nonce = nonce
+		if !header.HasValidProofOfWork() {
+			t.Fatalf("%d workers: nonce %d does not meet the target", workers, nonce)
+		}
+	}
+}
+
+func TestParallelSearchNonceMatchesTheSerialSearch(t *testing.T) {
+	// One hash in 256 meets the target, each worker finds one within a few hundred hashes.
+	easyBits := TargetToCompact(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 248), big.NewInt(1)))
+
+	for _, workers := range []int{2, 4, 8} {
+		header := benchmarkHeader()
+		header.Bits = easyBits
+		header.Nonce = 1 << 40
+
+		nonce, err := searchNonce(context.Background(), &header, workers, func(uint64) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Each worker walks its own range in order, so the nonce is the first valid one of a range, the one a
+		// serial search from the start of that range returns.
+		span := math.MaxUint64 / uint64(workers)
+		serial := header
+		serial.Nonce = header.Nonce + (nonce-header.Nonce)/span*span
+		serialNonce, err := searchNonce(context.Background(), &serial, 1, func(uint64) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nonce != serialNonce {
+			t.Fatalf("%d workers: got nonce %d, the serial search found %d", workers, nonce, serialNonce)
+		}
+
+		header.Nonce = nonce
+		if !header.HasValidProofOfWork() {
+			t.Fatalf("%d workers: nonce %d does not meet the target", workers, nonce)
+		}
+	}
+}
+
+func TestSearchNonceStopsWhenCancelled(t *testing.T) {
+	header := benchmarkHeader()
+	header.Bits = 0x03000001
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := searchNonce(ctx, &header, 2, func(uint64) {}); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline, got %v", err)
+	}
+}
+
+// BenchmarkSearchNonce mines one block per iteration. Compare hashes/s with BenchmarkCalculateHashLoop.
+func BenchmarkSearchNonce(b *testing.B) {
+	for _, test := range []struct {
+		name    string
+		workers int
+	}{
+		{"workers=1", 1},
+		{"workers=GOMAXPROCS", runtime.GOMAXPROCS(0)},
+	} {
+		workers := test.workers
+		b.Run(test.name, func(b *testing.B) {
+			header := benchmarkHeader()
+			var mutex sync.Mutex
+			var hashes uint64
+			count := func(done uint64) {
+				mutex.Lock()
+				hashes += done
+				mutex.Unlock()
+			}
+
+			b.ResetTimer()
+			for iteration := 0; iteration < b.N; iteration++ {
+				nonce, err := searchNonce(context.Background(), &header, workers, count)
+				if err != nil {
+					b.Fatal(err)
+				}
+				header.Nonce = nonce + 1
+			}
+			b.ReportMetric(float64(hashes)/b.Elapsed().Seconds(), "hashes/s")
+		})
+	}
+}
+
+// BenchmarkCalculateHashLoop is the baseline: the single goroutine loop mining used before the midstate search,
+// which hashed the whole header for every nonce. The sprintf case hashes the way the first blocks of the chain
+// did, a formatted string of the block fields.
+func BenchmarkCalculateHashLoop(b *testing.B) {
+	b.Run("canonical", func(b *testing.B) {
+		header := benchmarkHeader()
+		target := header.Target()
+		var hashes uint64
+
+		b.ResetTimer()
+		for iteration := 0; iteration < b.N; iteration++ {
+			header.Nonce++
+			for hashes++; !hashMeetsTarget(header.CalculateHash(), target); hashes++ {
+				header.Nonce++
+			}
+		}
+		b.ReportMetric(float64(hashes)/b.Elapsed().Seconds(), "hashes/s")
+	})
+
+	b.Run("sprintf", func(b *testing.B) {
+		block := goldenBlock()
+		target := CompactToTarget(benchmarkBits)
+		var hashes uint64
+
+		sprintfHash := func(nonce uint64) string {
+			var transactions string
+			for index := range block.Transactions {
+				transactions += hex.EncodeToString(block.Transactions[index].Signature)
+			}
+
+			hash := sha256.Sum256([]byte(fmt.Sprintf("%v%v%v%v", block.Header.TimeStamp, block.Header.PreviousHash, transactions, nonce)))
+			return hex.EncodeToString(hash[:])
+		}
+
+		b.ResetTimer()
+		for iteration := 0; iteration < b.N; iteration++ {
+			block.Header.Nonce++
+			for hashes++; !hashMeetsTarget(sprintfHash(block.Header.Nonce), target); hashes++ {
+				block.Header.Nonce++
+			}
+		}
+		b.ReportMetric(float64(hashes)/b.Elapsed().Seconds(), "hashes/s")
+	})
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	mappers "bitshare-chain/application/mappers"
+	services "bitshare-chain/application/services"
+	viewmodels "bitshare-chain/domain/view-models"
+	context "context"
+)
+
+// CheckAccountStateCommand rebuilds the account states from the chain and diffs them against the stored ones.
+// With Repair set, the rebuilt states replace the stored and the in-memory ones.
+type CheckAccountStateCommand struct {
+	Repair bool `json:"repair"`
+}
+
+type CheckAccountStateCommandHandler struct {
+	blockchainService *services.BlockchainService
+}
+
+func NewCheckAccountStateCommandHandler(blockchainService *services.BlockchainService) *CheckAccountStateCommandHandler {
+	return &CheckAccountStateCommandHandler{
+		blockchainService: blockchainService,
+	}
+}
+
+func (handler *CheckAccountStateCommandHandler) Handle(context context.Context, command CheckAccountStateCommand) (viewmodels.AccountStateCheckVM, error) {
+	diffs, err := handler.blockchainService.Blockchain().RepairAccountStates(command.Repair)
+	if err != nil {
+		return viewmodels.AccountStateCheckVM{}, err
+	}
+
+	result := viewmodels.AccountStateCheckVM{
+		Consistent:  len(diffs) == 0,
+		Repaired:    command.Repair && len(diffs) > 0,
+		Differences: make([]viewmodels.AccountStateDiffVM, 0, len(diffs)),
+	}
+
+	for _, diff := range diffs {
+		result.Differences = append(result.Differences, viewmodels.AccountStateDiffVM{
+			Address:  diff.Address,
+			Expected: mappers.ToAccountStateVM(diff.Expected),
+			Stored:   mappers.ToAccountStateVM(diff.Actual),
+		})
+	}
+
+	return result, nil
+}
@@ -3,46 +3,78 @@ package commands
 import (
 	documents "bitshare-chain/application/data-access/documents"
 	repositories "bitshare-chain/application/data-access/repositories"
+	mappers "bitshare-chain/application/mappers"
+	services "bitshare-chain/application/services"
 	validation "bitshare-chain/application/validation"
-	viewmodels "bitshare-chain/domain/view-models"
-	services "bitshare-chain/infrastructure/utilities"
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
 	context "context"
 	json "encoding/json"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type CreateWalletAccountCommand struct{}
+// CreateWalletAccountCommand creates an HD wallet from a new mnemonic. The seed is kept in the node's keystore,
+// encrypted with Passphrase. MnemonicPassphrase is the optional BIP-39 passphrase, it is needed along with the
+// mnemonic to recover the wallet.
+type CreateWalletAccountCommand struct {
+	Passphrase         string `json:"passphrase" validate:"required,min=8"`
+	MnemonicPassphrase string `json:"mnemonicPassphrase"`
+}
 
 type CreateWalletAccountCommandHandler struct {
 	walletAccountRepository repositories.WalletAccountRepository
-	keyGenerator            services.KeyGenerator
+	keystoreService         *services.KeystoreService
 	validator               *validation.Validator
 }
 
-func NewCreateWalletAccountCommandHandler(walletAccountRepository repositories.WalletAccountRepository, keyGenerator services.KeyGenerator, validator *validation.Validator) *CreateWalletAccountCommandHandler {
+func NewCreateWalletAccountCommandHandler(walletAccountRepository repositories.WalletAccountRepository, keystoreService *services.KeystoreService, validator *validation.Validator) *CreateWalletAccountCommandHandler {
 	return &CreateWalletAccountCommandHandler{
 		walletAccountRepository: walletAccountRepository,
-		keyGenerator:            keyGenerator,
+		keystoreService:         keystoreService,
 		validator:               validator,
 	}
 }
 
 func (handler *CreateWalletAccountCommandHandler) Handle(context context.Context, command CreateWalletAccountCommand) (interface{}, error) {
-	publicKey, privateKey := handler.keyGenerator.GeneratePublicAndPrivateKey()
+	if err := handler.validator.ValidateStruct(command); err != nil {
+		return nil, err
+	}
+
+	mnemonic, err := hdwallet.NewMnemonic(hdwallet.DefaultEntropyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := hdwallet.MnemonicToSeed(mnemonic, command.MnemonicPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	accountPath := hdwallet.AccountPath(0)
+	seedKeyId, keys, err := handler.keystoreService.CreateHDKeys(seed, command.Passphrase, []hdwallet.DerivationPath{accountPath.Child(0)})
+	if err != nil {
+		return nil, err
+	}
 
 	newWalletAccount := documents.WalletAccountDocument{
-		Address: publicKey,
+		ID:          primitive.NewObjectID(),
+		Address:     keys[0].Address,
+		SeedKeyId:   seedKeyId,
+		AccountPath: accountPath.String(),
+		Addresses:   []documents.WalletAddressSubDocument{mappers.ToWalletAddressSubDocument(0, keys[0])},
 	}
 
-	err := handler.walletAccountRepository.CreateWalletAccount(&newWalletAccount)
+	err = handler.walletAccountRepository.CreateWalletAccount(&newWalletAccount)
 	if err != nil {
 		// Handle error accordingly
 		return nil, err
 	}
 
-	walletKeysVM := viewmodels.NewWalletKeysVM(publicKey, privateKey, "")
+	walletAccountVM := mappers.ToWalletAccountVM(newWalletAccount)
+	walletAccountVM.Mnemonic = mnemonic
 
 	// Convert to JSON response
-	response, err := json.Marshal(walletKeysVM)
+	response, err := json.Marshal(walletAccountVM)
 	if err != nil {
 		// Handle error accordingly
 		return nil, err
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	repositories "bitshare-chain/application/data-access/repositories"
+	mappers "bitshare-chain/application/mappers"
+	services "bitshare-chain/application/services"
+	validation "bitshare-chain/application/validation"
+	viewmodels "bitshare-chain/domain/view-models"
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
+	context "context"
+	errors "errors"
+	fmt "fmt"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrWalletAccountNotFound = errors.New("wallet account not found")
+	ErrNotAnHDWallet         = errors.New("wallet account has no seed to derive addresses from")
+)
+
+// DeriveWalletAddressCommand derives the next address of an HD wallet, unlocking its seed with Passphrase.
+type DeriveWalletAddressCommand struct {
+	WalletId   string `json:"walletId" validate:"required"`
+	Passphrase string `json:"passphrase" validate:"required"`
+}
+
+type DeriveWalletAddressCommandHandler struct {
+	walletAccountRepository repositories.WalletAccountRepository
+	keystoreService         *services.KeystoreService
+	validator               *validation.Validator
+}
+
+func NewDeriveWalletAddressCommandHandler(walletAccountRepository repositories.WalletAccountRepository, keystoreService *services.KeystoreService, validator *validation.Validator) *DeriveWalletAddressCommandHandler {
+	return &DeriveWalletAddressCommandHandler{
+		walletAccountRepository: walletAccountRepository,
+		keystoreService:         keystoreService,
+		validator:               validator,
+	}
+}
+
+func (handler *DeriveWalletAddressCommandHandler) Handle(context context.Context, command DeriveWalletAddressCommand) (viewmodels.WalletAccountVM, error) {
+	if err := handler.validator.ValidateStruct(command); err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	walletId, err := primitive.ObjectIDFromHex(command.WalletId)
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, ErrWalletAccountNotFound
+	}
+
+	// The next index is read from the stored addresses, so a concurrent derivation must wait until this one wrote.
+	unlock := walletAccountLocks.Lock(walletId.Hex())
+	defer unlock()
+
+	walletAccount, err := handler.walletAccountRepository.GetWalletAccount(context, walletId)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return viewmodels.WalletAccountVM{}, ErrWalletAccountNotFound
+	}
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	if walletAccount.SeedKeyId == "" {
+		return viewmodels.WalletAccountVM{}, ErrNotAnHDWallet
+	}
+
+	accountPath, err := hdwallet.ParseDerivationPath(walletAccount.AccountPath)
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, fmt.Errorf("wallet account %s: %v", command.WalletId, err)
+	}
+
+	index := uint32(0)
+	for _, walletAddress := range walletAccount.Addresses {
+		index = max(index, walletAddress.Index+1)
+	}
+
+	key, err := handler.keystoreService.DeriveHDKey(walletAccount.SeedKeyId, command.Passphrase, accountPath.Child(index))
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	// A key whose address was not recorded belongs to no wallet account, so it goes again.
+	walletAddress := mappers.ToWalletAddressSubDocument(index, key)
+	if err := handler.walletAccountRepository.AddWalletAddress(context, walletId, walletAddress); err != nil {
+		if deleteErr := handler.keystoreService.DeleteHDKey(key.Id); deleteErr != nil {
+			return viewmodels.WalletAccountVM{}, fmt.Errorf("%w, and key %s could not be removed: %v", err, key.Id, deleteErr)
+		}
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	walletAccount.Addresses = append(walletAccount.Addresses, walletAddress)
+	return mappers.ToWalletAccountVM(walletAccount), nil
+}
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	repositories "bitshare-chain/application/data-access/repositories"
+	primitives "bitshare-chain/infrastructure/primitives"
+	context "context"
+	errors "errors"
+
+	mongo "go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateWalletAddressesCommand rewrites addresses stored before addresses were checksummed, when an address was
+// the hex encoded public key, into the current address format. Addresses that are already valid are kept, so the
+// migration can run on every start.
+type MigrateWalletAddressesCommand struct{}
+
+type MigrateWalletAddressesCommandHandler struct {
+	walletAccountRepository repositories.WalletAccountRepository
+	nodeMetadataRepository  repositories.NodeMetadataRepository
+}
+
+func NewMigrateWalletAddressesCommandHandler(walletAccountRepository repositories.WalletAccountRepository, nodeMetadataRepository repositories.NodeMetadataRepository) *MigrateWalletAddressesCommandHandler {
+	return &MigrateWalletAddressesCommandHandler{
+		walletAccountRepository: walletAccountRepository,
+		nodeMetadataRepository:  nodeMetadataRepository,
+	}
+}
+
+// Handle returns the number of migrated wallet accounts.
+func (handler *MigrateWalletAddressesCommandHandler) Handle(context context.Context, command MigrateWalletAddressesCommand) (int, error) {
+	walletAccounts, err := handler.walletAccountRepository.GetAllWalletAccounts(context)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, walletAccount := range walletAccounts {
+		if !migrateWalletAccount(&walletAccount) {
+			continue
+		}
+
+		if err := handler.walletAccountRepository.ReplaceWalletAccount(context, walletAccount); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	nodeMetadata, err := handler.nodeMetadataRepository.GetRewardAddress(context)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return migrated, nil
+	}
+
+	if err != nil {
+		return migrated, err
+	}
+
+	if rewardAddress, ok := migrateAddress(nodeMetadata.RewardAddress); ok {
+		if _, err := handler.nodeMetadataRepository.InsertMetadataRewardAddress(rewardAddress); err != nil {
+			return migrated, err
+		}
+	}
+
+	return migrated, nil
+}
+
+func migrateWalletAccount(walletAccount *documents.WalletAccountDocument) bool {
+	changed := false
+	migrate := func(address *string) {
+		if migratedAddress, ok := migrateAddress(*address); ok {
+			*address = migratedAddress
+			changed = true
+		}
+	}
+
+	migrate(&walletAccount.Address)
+	for index := range walletAccount.Transactions {
+		migrate(&walletAccount.Transactions[index].FromAddress)
+		migrate(&walletAccount.Transactions[index].ToAddress)
+	}
+
+	return changed
+}
+
+// migrateAddress converts a hex encoded public key into its address.
+func migrateAddress(address string) (string, bool) {
+	if address == "" || primitives.ValidateAddress(address) == nil {
+		return "", false
+	}
+
+	migratedAddress, err := primitives.PublicKeyHexToAddress(address)
+	if err != nil {
+		return "", false
+	}
+
+	return migratedAddress, true
+}
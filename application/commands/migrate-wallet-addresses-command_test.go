@@ -0,0 +1,68 @@
+package commands
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	primitives "bitshare-chain/infrastructure/primitives"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	hex "encoding/hex"
+	reflect "reflect"
+	testing "testing"
+)
+
+func newTestPublicKey(t *testing.T) *ecdsa.PublicKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &key.PublicKey
+}
+
+func TestMigrateWalletAccount(t *testing.T) {
+	owner := newTestPublicKey(t)
+	recipient := newTestPublicKey(t)
+	sender := newTestPublicKey(t)
+
+	// Old wallets stored the hex encoded public key in either form, newer entries are already addresses.
+	walletAccount := documents.WalletAccountDocument{
+		Address: hex.EncodeToString(primitives.MarshalPublicKey(owner)),
+		Transactions: []documents.TransactionSubDocument{
+			{FromAddress: hex.EncodeToString(elliptic.Marshal(elliptic.P256(), owner.X, owner.Y)), ToAddress: hex.EncodeToString(primitives.MarshalPublicKey(recipient))},
+			{FromAddress: primitives.PublicKeyToAddress(sender), ToAddress: primitives.PublicKeyToAddress(owner)},
+			{ToAddress: hex.EncodeToString(primitives.MarshalPublicKey(owner))},
+		},
+	}
+	expected := documents.WalletAccountDocument{
+		Address: primitives.PublicKeyToAddress(owner),
+		Transactions: []documents.TransactionSubDocument{
+			{FromAddress: primitives.PublicKeyToAddress(owner), ToAddress: primitives.PublicKeyToAddress(recipient)},
+			{FromAddress: primitives.PublicKeyToAddress(sender), ToAddress: primitives.PublicKeyToAddress(owner)},
+			{ToAddress: primitives.PublicKeyToAddress(owner)},
+		},
+	}
+
+	if !migrateWalletAccount(&walletAccount) {
+		t.Fatal("the first run did not migrate the wallet account")
+	}
+	if !reflect.DeepEqual(walletAccount, expected) {
+		t.Fatalf("got %+v, want %+v", walletAccount, expected)
+	}
+
+	if migrateWalletAccount(&walletAccount) {
+		t.Fatal("the second run changed the migrated wallet account")
+	}
+	if !reflect.DeepEqual(walletAccount, expected) {
+		t.Fatalf("the second run left %+v, want %+v", walletAccount, expected)
+	}
+}
+
+func TestMigrateAddressKeepsWhatItCannotConvert(t *testing.T) {
+	for _, address := range []string{"", primitives.PublicKeyToAddress(newTestPublicKey(t)), "not hex", "02ab"} {
+		if migrated, ok := migrateAddress(address); ok {
+			t.Fatalf("%q was migrated to %q", address, migrated)
+		}
+	}
+}
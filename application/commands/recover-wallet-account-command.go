@@ -0,0 +1,140 @@
+package commands
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	repositories "bitshare-chain/application/data-access/repositories"
+	mappers "bitshare-chain/application/mappers"
+	services "bitshare-chain/application/services"
+	validation "bitshare-chain/application/validation"
+	viewmodels "bitshare-chain/domain/view-models"
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
+	keystore "bitshare-chain/infrastructure/keystore"
+	context "context"
+	errors "errors"
+	fmt "fmt"
+
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+)
+
+// RecoverWalletAccountCommand restores an HD wallet from its mnemonic. The addresses of the wallet are scanned
+// from the first on until GapLimit consecutive addresses never appeared on the chain, every address up to the
+// last used one is restored. The seed is stored in the keystore encrypted with Passphrase.
+type RecoverWalletAccountCommand struct {
+	Mnemonic           string `json:"mnemonic" validate:"required"`
+	MnemonicPassphrase string `json:"mnemonicPassphrase"`
+	Passphrase         string `json:"passphrase" validate:"required,min=8"`
+	GapLimit           int    `json:"gapLimit" validate:"min=0,max=1000"`
+}
+
+type RecoverWalletAccountCommandHandler struct {
+	walletAccountRepository repositories.WalletAccountRepository
+	keystoreService         *services.KeystoreService
+	blockchainService       *services.BlockchainService
+	validator               *validation.Validator
+}
+
+func NewRecoverWalletAccountCommandHandler(walletAccountRepository repositories.WalletAccountRepository, keystoreService *services.KeystoreService, blockchainService *services.BlockchainService, validator *validation.Validator) *RecoverWalletAccountCommandHandler {
+	return &RecoverWalletAccountCommandHandler{
+		walletAccountRepository: walletAccountRepository,
+		keystoreService:         keystoreService,
+		blockchainService:       blockchainService,
+		validator:               validator,
+	}
+}
+
+func (handler *RecoverWalletAccountCommandHandler) Handle(context context.Context, command RecoverWalletAccountCommand) (viewmodels.WalletAccountVM, error) {
+	if err := handler.validator.ValidateStruct(command); err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	seed, err := hdwallet.MnemonicToSeed(command.Mnemonic, command.MnemonicPassphrase)
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	master, err := hdwallet.NewMasterKey(seed)
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	accountPath := hdwallet.AccountPath(0)
+	discovered, err := hdwallet.DiscoverAddresses(master, accountPath, command.GapLimit, func(address string) (bool, error) {
+		return handler.blockchainService.IsAddressUsed(address), nil
+	})
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	// A wallet that was never used still gets its first address back.
+	paths := []hdwallet.DerivationPath{accountPath.Child(0)}
+	if len(discovered) > 0 {
+		paths = paths[:0]
+		for _, address := range discovered {
+			paths = append(paths, address.Path)
+		}
+	}
+
+	seedKeyId, keys, err := handler.keystoreService.CreateHDKeys(seed, command.Passphrase, paths)
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, err
+	}
+
+	addresses := []documents.WalletAddressSubDocument{}
+	for index, key := range keys {
+		addresses = append(addresses, mappers.ToWalletAddressSubDocument(uint32(index), key))
+	}
+
+	// A wallet account that is still stored, e.g. when only the keystore was lost, is recovered in place.
+	walletAccount, err := handler.walletAccountRepository.GetWalletAccountByAddress(context, keys[0].Address)
+	stored := err == nil
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		walletAccount = documents.WalletAccountDocument{ID: primitive.NewObjectID()}
+	} else if err != nil {
+		return viewmodels.WalletAccountVM{}, handler.discardSeed(seedKeyId, err)
+	}
+
+	if stored {
+		unlock := walletAccountLocks.Lock(walletAccount.ID.Hex())
+		defer unlock()
+
+		// Read again under the lock, a derivation may have stored an address since.
+		walletAccount, err = handler.walletAccountRepository.GetWalletAccount(context, walletAccount.ID)
+		if err != nil {
+			return viewmodels.WalletAccountVM{}, handler.discardSeed(seedKeyId, err)
+		}
+	}
+
+	previousSeedKeyId := walletAccount.SeedKeyId
+	walletAccount.Address = keys[0].Address
+	walletAccount.SeedKeyId = seedKeyId
+	walletAccount.AccountPath = accountPath.String()
+	walletAccount.Addresses = addresses
+
+	if stored {
+		err = handler.walletAccountRepository.ReplaceWalletAccount(context, walletAccount)
+	} else {
+		err = handler.walletAccountRepository.CreateWalletAccount(&walletAccount)
+	}
+	if err != nil {
+		return viewmodels.WalletAccountVM{}, handler.discardSeed(seedKeyId, err)
+	}
+
+	// The wallet account now points to the new seed, the one it was stored with is of no use anymore.
+	if previousSeedKeyId != "" && previousSeedKeyId != seedKeyId {
+		if err := handler.keystoreService.DeleteHDKeys(previousSeedKeyId); err != nil && !errors.Is(err, keystore.ErrKeyNotFound) {
+			return viewmodels.WalletAccountVM{}, fmt.Errorf("wallet account recovered, but its previous seed %s could not be removed: %v", previousSeedKeyId, err)
+		}
+	}
+
+	return mappers.ToWalletAccountVM(walletAccount), nil
+}
+
+// discardSeed removes the seed stored for a recovery that failed, so that no wallet account is left without it
+// and no seed is left without a wallet account.
+func (handler *RecoverWalletAccountCommandHandler) discardSeed(seedKeyId string, err error) error {
+	if deleteErr := handler.keystoreService.DeleteHDKeys(seedKeyId); deleteErr != nil {
+		return fmt.Errorf("%w, and its seed %s could not be removed: %v", err, seedKeyId, deleteErr)
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package commands
+
+import (
+	sync "sync"
+)
+
+// walletAccountLocks serializes the commands that change the addresses of a wallet account, so that two
+// requests never allocate the same address index of its seed.
+var walletAccountLocks = &keyedMutex{}
+
+// keyedMutex holds one mutex per key. Mutexes are never dropped, there is one per wallet account at most.
+type keyedMutex struct {
+	mutexes sync.Map
+}
+
+// Lock locks the mutex of the key and returns the function that unlocks it.
+func (keyed *keyedMutex) Lock(key string) func() {
+	value, _ := keyed.mutexes.LoadOrStore(key, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
@@ -0,0 +1,69 @@
+package mongo_context
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	fmt "fmt"
+	reflect "reflect"
+	strconv "strconv"
+
+	bson "go.mongodb.org/mongo-driver/bson"
+	bsoncodec "go.mongodb.org/mongo-driver/bson/bsoncodec"
+	bsonrw "go.mongodb.org/mongo-driver/bson/bsonrw"
+)
+
+var amountType = reflect.TypeOf(primitives.Amount(0))
+
+// NewRegistry returns the default BSON registry with the codecs of the chain types added. Amounts are stored as
+// an int64 count of the smallest unit.
+func NewRegistry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeEncoder(amountType, bsoncodec.ValueEncoderFunc(encodeAmount))
+	registry.RegisterTypeDecoder(amountType, bsoncodec.ValueDecoderFunc(decodeAmount))
+	return registry
+}
+
+func encodeAmount(_ bsoncodec.EncodeContext, writer bsonrw.ValueWriter, value reflect.Value) error {
+	if !value.IsValid() || value.Type() != amountType {
+		return bsoncodec.ValueEncoderError{Name: "AmountEncodeValue", Types: []reflect.Type{amountType}, Received: value}
+	}
+
+	return writer.WriteInt64(value.Int())
+}
+
+func decodeAmount(_ bsoncodec.DecodeContext, reader bsonrw.ValueReader, value reflect.Value) error {
+	if !value.CanSet() || value.Type() != amountType {
+		return bsoncodec.ValueDecoderError{Name: "AmountDecodeValue", Types: []reflect.Type{amountType}, Received: value}
+	}
+
+	var amount primitives.Amount
+	switch reader.Type() {
+	case bson.TypeInt64:
+		units, err := reader.ReadInt64()
+		if err != nil {
+			return err
+		}
+		amount = primitives.Amount(units)
+	case bson.TypeInt32:
+		units, err := reader.ReadInt32()
+		if err != nil {
+			return err
+		}
+		amount = primitives.Amount(units)
+	case bson.TypeDouble:
+		// Documents written before amounts became fixed point stored coins as a double.
+		coins, err := reader.ReadDouble()
+		if err != nil {
+			return err
+		}
+		parsed, err := primitives.ParseAmount(strconv.FormatFloat(coins, 'f', primitives.AmountDecimals, 64))
+		if err != nil {
+			return err
+		}
+		amount = parsed
+	default:
+		return fmt.Errorf("%w: cannot decode amount from BSON %s", primitives.ErrInvalidAmount, reader.Type())
+	}
+
+	value.SetInt(int64(amount))
+	return nil
+}
@@ -0,0 +1,99 @@
+package mongo_context
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	bytes "bytes"
+	errors "errors"
+	math "math"
+	testing "testing"
+
+	bson "go.mongodb.org/mongo-driver/bson"
+	bsonrw "go.mongodb.org/mongo-driver/bson/bsonrw"
+)
+
+type amountDocument struct {
+	Amount primitives.Amount `bson:"amount"`
+}
+
+func encodeWithRegistry(t *testing.T, document interface{}) []byte {
+	t.Helper()
+
+	buffer := new(bytes.Buffer)
+	writer, err := bsonrw.NewBSONValueWriter(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder, err := bson.NewEncoder(writer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoder.SetRegistry(NewRegistry())
+	if err := encoder.Encode(document); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func decodeWithRegistry(data []byte, document interface{}) error {
+	decoder, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(data))
+	if err != nil {
+		return err
+	}
+	decoder.SetRegistry(NewRegistry())
+	return decoder.Decode(document)
+}
+
+func TestAmountCodecRoundTrip(t *testing.T) {
+	for _, amount := range []primitives.Amount{0, 1, -1, primitives.AmountUnitsPerCoin, math.MaxInt64, math.MinInt64} {
+		data := encodeWithRegistry(t, amountDocument{Amount: amount})
+
+		if stored := bson.Raw(data).Lookup("amount"); stored.Type != bson.TypeInt64 || stored.Int64() != int64(amount) {
+			t.Fatalf("%d is stored as %s %v", int64(amount), stored.Type, stored)
+		}
+
+		var decoded amountDocument
+		if err := decodeWithRegistry(data, &decoded); err != nil || decoded.Amount != amount {
+			t.Fatalf("got %d, %v, want %d", decoded.Amount, err, amount)
+		}
+	}
+}
+
+func TestAmountCodecDecodesLegacyValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   interface{}
+		expected primitives.Amount
+	}{
+		{"int32", int32(42), 42},
+		{"double coins", 12.5, 1_250_000_000},
+		{"inexact double", 0.1, 10_000_000},
+		{"smallest unit double", 0.00000001, 1},
+		{"whole double", 3.0, 3 * primitives.AmountUnitsPerCoin},
+	}
+
+	for _, test := range tests {
+		data, err := bson.Marshal(bson.D{{Key: "amount", Value: test.stored}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded amountDocument
+		if err := decodeWithRegistry(data, &decoded); err != nil || decoded.Amount != test.expected {
+			t.Fatalf("%s: got %d, %v, want %d", test.name, decoded.Amount, err, test.expected)
+		}
+	}
+}
+
+func TestAmountCodecRejectsOtherTypes(t *testing.T) {
+	for _, stored := range []interface{}{"12.5", true, -1.5} {
+		data, err := bson.Marshal(bson.D{{Key: "amount", Value: stored}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded amountDocument
+		if err := decodeWithRegistry(data, &decoded); !errors.Is(err, primitives.ErrInvalidAmount) {
+			t.Fatalf("%v: got %v, want %v", stored, err, primitives.ErrInvalidAmount)
+		}
+	}
+}
@@ -14,7 +14,7 @@ type MongoContext struct {
 }
 
 func NewMongoContext(dbOptions *settings.MongoDbOptions) (*MongoContext, error) {
-	clientOptions := options.Client()
+	clientOptions := options.Client().SetRegistry(NewRegistry())
 	client, err := mongo.Connect(context.Background(), clientOptions.ApplyURI(dbOptions.ConnectionString))
 	if err != nil {
 		return nil, err
@@ -0,0 +1,12 @@
+package documents
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+)
+
+type AccountStateDocument struct {
+	Address            string            `bson:"_id"`
+	Balance            primitives.Amount `bson:"balance"`
+	Nonce              uint64            `bson:"nonce"`
+	LastActivityHeight int64             `bson:"lastActivityHeight"`
+}
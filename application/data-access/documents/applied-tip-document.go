@@ -0,0 +1,8 @@
+package documents
+
+// AppliedTipDocument is the single document recording the block the stored account states were applied up to.
+type AppliedTipDocument struct {
+	Id     string `bson:"_id"`
+	Height int64  `bson:"height"`
+	Hash   string `bson:"hash"`
+}
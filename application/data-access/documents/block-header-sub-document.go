@@ -0,0 +1,18 @@
+package documents
+
+import (
+	time "time"
+)
+
+type BlockHeaderSubDocument struct {
+	Version           uint32    `bson:"version"`
+	Height            int64     `bson:"height"`
+	PreviousHash      string    `bson:"previousHash,omitempty"`
+	MerkleRoot        string    `bson:"merkleRoot,omitempty"`
+	TimeStamp         time.Time `bson:"timeStamp,omitempty"`
+	Bits              uint32    `bson:"bits"`
+	Nonce             uint64    `bson:"nonce"`
+	BlockMinerAddress string    `bson:"blockMinerAddress,omitempty"`
+	MinerPublicKey    []byte    `bson:"minerPublicKey,omitempty"`
+	BlockSignature    []byte    `bson:"blockSignature,omitempty"`
+}
@@ -2,8 +2,13 @@ package documents
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// WalletAccountDocument is a wallet account. HD wallets keep their seed in the keystore under SeedKeyId and own
+// every address derived below AccountPath, Address is then the first of them.
 type WalletAccountDocument struct {
-	ID           primitive.ObjectID       `bson:"_id,omitempty"`
-	Address      string                   `bson:"address,omitempty"`
-	Transactions []TransactionSubDocument `bson:"transactions,omitempty"`
+	ID           primitive.ObjectID         `bson:"_id,omitempty"`
+	Address      string                     `bson:"address,omitempty"`
+	SeedKeyId    string                     `bson:"seedKeyId,omitempty"`
+	AccountPath  string                     `bson:"accountPath,omitempty"`
+	Addresses    []WalletAddressSubDocument `bson:"addresses,omitempty"`
+	Transactions []TransactionSubDocument   `bson:"transactions,omitempty"`
 }
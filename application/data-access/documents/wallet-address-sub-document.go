@@ -0,0 +1,10 @@
+package documents
+
+// WalletAddressSubDocument is an address derived from the seed of an HD wallet, signed for with the keystore
+// key KeyId.
+type WalletAddressSubDocument struct {
+	Index   uint32 `bson:"index"`
+	Path    string `bson:"path"`
+	Address string `bson:"address"`
+	KeyId   string `bson:"keyId"`
+}
@@ -1 +1,153 @@
 package repositories
+
+import (
+	context "context"
+	errors "errors"
+	fmt "fmt"
+
+	mongo_context "bitshare-chain/application/data-access/context"
+	documents "bitshare-chain/application/data-access/documents"
+
+	bson "go.mongodb.org/mongo-driver/bson"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrBlockNotFound    = errors.New("block not found")
+	ErrBlockHeightTaken = errors.New("a block is already stored at this height")
+	ErrBlockNotOnTip    = errors.New("block does not extend the current tip")
+)
+
+type BlockchainRepository interface {
+	AppendBlock(ctx context.Context, block *documents.BlockDocument) error
+	GetBlockByIndex(ctx context.Context, index int64) (*documents.BlockDocument, error)
+	GetBlockByHash(ctx context.Context, hash string) (*documents.BlockDocument, error)
+	GetTip(ctx context.Context) (*documents.BlockDocument, error)
+	GetBlocksInRange(ctx context.Context, fromIndex int64, toIndex int64) ([]documents.BlockDocument, error)
+	GetHeadersInRange(ctx context.Context, fromIndex int64, toIndex int64) ([]documents.BlockHeaderSubDocument, error)
+	DeleteBlocksAbove(ctx context.Context, index int64) error
+}
+
+type blockchainRepository struct {
+	blockCollection *mongo.Collection
+}
+
+func NewBlockchainRepository(mongoContext *mongo_context.MongoContext) (BlockchainRepository, error) {
+	blockCollection := mongoContext.Database.Collection("BlockDocument")
+
+	// The unique index on the height is what makes an append atomic: the block and its
+	// height entry are one document, so a second block for the same height can never land.
+	_, err := blockCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "index", Value: 1}},
+			Options: options.Index().SetName("blockIndexUnique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetName("blockHashUnique").SetUnique(true),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create block indexes: %v", err)
+	}
+
+	return &blockchainRepository{
+		blockCollection: blockCollection,
+	}, nil
+}
+
+func (r *blockchainRepository) AppendBlock(ctx context.Context, block *documents.BlockDocument) error {
+	tip, err := r.GetTip(ctx)
+	if err != nil && err != ErrBlockNotFound {
+		return err
+	}
+
+	if tip == nil && block.Index != 0 {
+		return ErrBlockNotOnTip
+	}
+
+	if tip != nil && (block.Index != tip.Index+1 || block.Header.PreviousHash != tip.Hash) {
+		return ErrBlockNotOnTip
+	}
+
+	block.ID = block.Hash
+	_, err = r.blockCollection.InsertOne(ctx, block)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrBlockHeightTaken
+	}
+
+	return err
+}
+
+func (r *blockchainRepository) GetBlockByIndex(ctx context.Context, index int64) (*documents.BlockDocument, error) {
+	return r.findOne(ctx, bson.M{"index": index})
+}
+
+func (r *blockchainRepository) GetBlockByHash(ctx context.Context, hash string) (*documents.BlockDocument, error) {
+	return r.findOne(ctx, bson.M{"hash": hash})
+}
+
+func (r *blockchainRepository) GetTip(ctx context.Context) (*documents.BlockDocument, error) {
+	return r.findOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "index", Value: -1}}))
+}
+
+func (r *blockchainRepository) GetBlocksInRange(ctx context.Context, fromIndex int64, toIndex int64) ([]documents.BlockDocument, error) {
+	filter := bson.M{"index": bson.M{"$gte": fromIndex, "$lte": toIndex}}
+	cursor, err := r.blockCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "index", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []documents.BlockDocument{}
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// GetHeadersInRange loads only the headers, the transactions are never read from the database.
+func (r *blockchainRepository) GetHeadersInRange(ctx context.Context, fromIndex int64, toIndex int64) ([]documents.BlockHeaderSubDocument, error) {
+	filter := bson.M{"index": bson.M{"$gte": fromIndex, "$lte": toIndex}}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "index", Value: 1}}).
+		SetProjection(bson.M{"header": 1})
+
+	cursor, err := r.blockCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []documents.BlockDocument{}
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+
+	headers := make([]documents.BlockHeaderSubDocument, 0, len(blocks))
+	for _, block := range blocks {
+		headers = append(headers, block.Header)
+	}
+
+	return headers, nil
+}
+
+// DeleteBlocksAbove removes every block higher than index, e.g. the old branch of a reorganization.
+func (r *blockchainRepository) DeleteBlocksAbove(ctx context.Context, index int64) error {
+	_, err := r.blockCollection.DeleteMany(ctx, bson.M{"index": bson.M{"$gt": index}})
+	return err
+}
+
+func (r *blockchainRepository) findOne(ctx context.Context, filter bson.M, findOptions ...*options.FindOneOptions) (*documents.BlockDocument, error) {
+	block := &documents.BlockDocument{}
+	err := r.blockCollection.FindOne(ctx, filter, findOptions...).Decode(block)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrBlockNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	context "context"
+	errors "errors"
+	fmt "fmt"
+
+	mongo_context "bitshare-chain/application/data-access/context"
+	documents "bitshare-chain/application/data-access/documents"
+
+	bson "go.mongodb.org/mongo-driver/bson"
+	mongo "go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// appliedTipId is the id of the only document in the applied tip collection.
+const appliedTipId = "appliedTip"
+
+var ErrAppliedTipNotFound = errors.New("no applied tip stored")
+
+type AccountStateRepository interface {
+	GetAccountState(ctx context.Context, address string) (*documents.AccountStateDocument, error)
+	GetAllAccountStates(ctx context.Context) ([]documents.AccountStateDocument, error)
+	GetAppliedTip(ctx context.Context) (*documents.AppliedTipDocument, error)
+	SaveAccountStates(ctx context.Context, updated []documents.AccountStateDocument, removed []string, tip documents.AppliedTipDocument) error
+}
+
+type accountStateRepository struct {
+	accountStateCollection *mongo.Collection
+	appliedTipCollection   *mongo.Collection
+}
+
+func NewAccountStateRepository(mongoContext *mongo_context.MongoContext) AccountStateRepository {
+	return &accountStateRepository{
+		accountStateCollection: mongoContext.Database.Collection("AccountStateDocument"),
+		appliedTipCollection:   mongoContext.Database.Collection("AppliedTipDocument"),
+	}
+}
+
+func (r *accountStateRepository) GetAccountState(ctx context.Context, address string) (*documents.AccountStateDocument, error) {
+	accountState := &documents.AccountStateDocument{}
+	err := r.accountStateCollection.FindOne(ctx, bson.M{"_id": address}).Decode(accountState)
+	if err != nil {
+		return nil, err
+	}
+	return accountState, nil
+}
+
+func (r *accountStateRepository) GetAllAccountStates(ctx context.Context) ([]documents.AccountStateDocument, error) {
+	cursor, err := r.accountStateCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	accountStates := []documents.AccountStateDocument{}
+	if err := cursor.All(ctx, &accountStates); err != nil {
+		return nil, err
+	}
+
+	return accountStates, nil
+}
+
+func (r *accountStateRepository) GetAppliedTip(ctx context.Context) (*documents.AppliedTipDocument, error) {
+	tip := &documents.AppliedTipDocument{}
+	err := r.appliedTipCollection.FindOne(ctx, bson.M{"_id": appliedTipId}).Decode(tip)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAppliedTipNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return tip, nil
+}
+
+// SaveAccountStates upserts the updated states and deletes the removed addresses in one ordered bulk write.
+// The applied tip is only written once the bulk write succeeded, so an interrupted save leaves the old tip.
+func (r *accountStateRepository) SaveAccountStates(ctx context.Context, updated []documents.AccountStateDocument, removed []string, tip documents.AppliedTipDocument) error {
+	writes := make([]mongo.WriteModel, 0, len(updated)+len(removed))
+	for index := range updated {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": updated[index].Address}).
+			SetReplacement(updated[index]).
+			SetUpsert(true))
+	}
+
+	for _, address := range removed {
+		writes = append(writes, mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": address}))
+	}
+
+	if len(writes) > 0 {
+		if _, err := r.accountStateCollection.BulkWrite(ctx, writes); err != nil {
+			return fmt.Errorf("failed to save account states: %v", err)
+		}
+	}
+
+	tip.Id = appliedTipId
+	if _, err := r.appliedTipCollection.ReplaceOne(ctx, bson.M{"_id": appliedTipId}, tip, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("failed to save the applied tip: %v", err)
+	}
+
+	return nil
+}
@@ -11,7 +11,7 @@ import (
 )
 
 type INodeMetadataRepository interface {
-	InsertMetadataPublicKey(publicKey string) (string, error)
+	InsertMetadataRewardAddress(rewardAddress string) (string, error)
 	GetRewardAddress(ctx context.Context) (*documents.NodeMetadataDocument, error)
 	GetNodeConnections(ctx context.Context) ([]documents.NodeConnectionsSubDocument, error)
 	UpdateNodeMetadataConnections(ctx context.Context, nodeMetadataSubDocuments []documents.NodeConnectionsSubDocument) error
@@ -28,7 +28,7 @@ func NewNodeMetadataRepository(mongoContext *mongo_context.MongoContext) *NodeMe
 	}
 }
 
-func (repo *NodeMetadataRepository) InsertMetadataPublicKey(publicKey string) (string, error) {
+func (repo *NodeMetadataRepository) InsertMetadataRewardAddress(rewardAddress string) (string, error) {
 	filter := bson.M{}
 	nodeMetadata := &documents.NodeMetadataDocument{}
 	err := repo.nodeMetadata.FindOne(context.Background(), filter).Decode(nodeMetadata)
@@ -36,7 +36,7 @@ func (repo *NodeMetadataRepository) InsertMetadataPublicKey(publicKey string) (s
 	if err == mongo.ErrNoDocuments {
 		newNodeMetadata := &documents.NodeMetadataDocument{
 			NodeId:        uuid.NewString(),
-			RewardAddress: publicKey,
+			RewardAddress: rewardAddress,
 		}
 
 		_, err := repo.nodeMetadata.InsertOne(context.Background(), newNodeMetadata)
@@ -45,7 +45,7 @@ func (repo *NodeMetadataRepository) InsertMetadataPublicKey(publicKey string) (s
 		}
 		return newNodeMetadata.NodeId, nil
 	} else if err == nil {
-		update := bson.M{"$set": bson.M{"RewardAddress": publicKey}}
+		update := bson.M{"$set": bson.M{"rewardAddress": rewardAddress}}
 		_, err := repo.nodeMetadata.UpdateOne(context.Background(), filter, update)
 		if err != nil {
 			return "", err
@@ -2,18 +2,27 @@ package repositories
 
 import (
 	context "context"
+	errors "errors"
 	fmt "fmt"
 
 	mongo_context "bitshare-chain/application/data-access/context"
 	documents "bitshare-chain/application/data-access/documents"
 
 	bson "go.mongodb.org/mongo-driver/bson"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 	mongo "go.mongodb.org/mongo-driver/mongo"
 )
 
+var ErrWalletAddressIndexTaken = errors.New("the wallet account already has an address at this index")
+
 type WalletAccountRepository interface {
 	CreateWalletAccount(newWalletAccount *documents.WalletAccountDocument) error
 	UpdateWalletAccountTransactions(walletTransactions map[string][]documents.TransactionSubDocument) error
+	GetAllWalletAccounts(ctx context.Context) ([]documents.WalletAccountDocument, error)
+	ReplaceWalletAccount(ctx context.Context, walletAccount documents.WalletAccountDocument) error
+	GetWalletAccount(ctx context.Context, id primitive.ObjectID) (documents.WalletAccountDocument, error)
+	GetWalletAccountByAddress(ctx context.Context, address string) (documents.WalletAccountDocument, error)
+	AddWalletAddress(ctx context.Context, id primitive.ObjectID, walletAddress documents.WalletAddressSubDocument) error
 }
 
 type walletAccountRepository struct {
@@ -44,3 +53,62 @@ func (r *walletAccountRepository) UpdateWalletAccountTransactions(walletTransact
 
 	return nil
 }
+
+func (r *walletAccountRepository) GetAllWalletAccounts(ctx context.Context) ([]documents.WalletAccountDocument, error) {
+	cursor, err := r.walletAccountCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	walletAccounts := []documents.WalletAccountDocument{}
+	if err := cursor.All(ctx, &walletAccounts); err != nil {
+		return nil, err
+	}
+
+	return walletAccounts, nil
+}
+
+func (r *walletAccountRepository) ReplaceWalletAccount(ctx context.Context, walletAccount documents.WalletAccountDocument) error {
+	_, err := r.walletAccountCollection.ReplaceOne(ctx, bson.M{"_id": walletAccount.ID}, walletAccount)
+	if err != nil {
+		return fmt.Errorf("failed to replace wallet account: %v", err)
+	}
+
+	return nil
+}
+
+func (r *walletAccountRepository) GetWalletAccount(ctx context.Context, id primitive.ObjectID) (documents.WalletAccountDocument, error) {
+	walletAccount := documents.WalletAccountDocument{}
+	err := r.walletAccountCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&walletAccount)
+	return walletAccount, err
+}
+
+// GetWalletAccountByAddress finds the wallet account owning the address, as its main or as a derived address.
+func (r *walletAccountRepository) GetWalletAccountByAddress(ctx context.Context, address string) (documents.WalletAccountDocument, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"address": address},
+		bson.M{"addresses.address": address},
+	}}
+
+	walletAccount := documents.WalletAccountDocument{}
+	err := r.walletAccountCollection.FindOne(ctx, filter).Decode(&walletAccount)
+	return walletAccount, err
+}
+
+// AddWalletAddress appends a derived address unless the wallet account already has one at the same index.
+func (r *walletAccountRepository) AddWalletAddress(ctx context.Context, id primitive.ObjectID, walletAddress documents.WalletAddressSubDocument) error {
+	filter := bson.M{"_id": id, "addresses.index": bson.M{"$ne": walletAddress.Index}}
+	update := bson.M{"$push": bson.M{"addresses": walletAddress}}
+
+	result, err := r.walletAccountCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to add wallet address: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrWalletAddressIndexTaken
+	}
+
+	return nil
+}
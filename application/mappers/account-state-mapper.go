@@ -0,0 +1,38 @@
+package mappers
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	viewmodels "bitshare-chain/domain/view-models"
+	utilities "bitshare-chain/infrastructure/utilities"
+)
+
+func ToAccountStateDocument(accountState utilities.AccountState) documents.AccountStateDocument {
+	return documents.AccountStateDocument{
+		Address:            accountState.Address,
+		Balance:            accountState.Balance,
+		Nonce:              accountState.Nonce,
+		LastActivityHeight: accountState.LastActivityHeight,
+	}
+}
+
+func FromAccountStateDocument(accountState documents.AccountStateDocument) utilities.AccountState {
+	return utilities.AccountState{
+		Address:            accountState.Address,
+		Balance:            accountState.Balance,
+		Nonce:              accountState.Nonce,
+		LastActivityHeight: accountState.LastActivityHeight,
+	}
+}
+
+func ToAccountStateVM(accountState *utilities.AccountState) *viewmodels.AccountStateVM {
+	if accountState == nil {
+		return nil
+	}
+
+	return &viewmodels.AccountStateVM{
+		Address:            accountState.Address,
+		Balance:            accountState.Balance.String(),
+		Nonce:              accountState.Nonce,
+		LastActivityHeight: accountState.LastActivityHeight,
+	}
+}
@@ -0,0 +1,72 @@
+package mappers
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	primitives "bitshare-chain/infrastructure/primitives"
+	utilities "bitshare-chain/infrastructure/utilities"
+	fmt "fmt"
+)
+
+func ToBlockDocument(block utilities.Block) *documents.BlockDocument {
+	transactions := make([]documents.TransactionSubDocument, 0, len(block.Transactions))
+	for _, transaction := range block.Transactions {
+		transactions = append(transactions, ToTransactionSubDocument(transaction))
+	}
+
+	return &documents.BlockDocument{
+		Index:        block.Header.Height,
+		Hash:         block.Hash,
+		Header:       ToBlockHeaderSubDocument(block.Header),
+		Transactions: transactions,
+	}
+}
+
+// FromBlockDocument recomputes the hash of the stored header, a document whose hash does not match is rejected.
+func FromBlockDocument(blockDocument documents.BlockDocument) (utilities.Block, error) {
+	transactions := make([]primitives.BlockTransaction, 0, len(blockDocument.Transactions))
+	for _, transaction := range blockDocument.Transactions {
+		transactions = append(transactions, FromTransactionSubDocument(transaction))
+	}
+
+	block := utilities.Block{
+		Header:       FromBlockHeaderSubDocument(blockDocument.Header),
+		Transactions: transactions,
+	}
+	block.Hash = block.CalculateHash()
+
+	if block.Hash != blockDocument.Hash {
+		return utilities.Block{}, fmt.Errorf("%w: block %d is stored as %s but hashes to %s", utilities.ErrInvalidBlockHeader, blockDocument.Index, blockDocument.Hash, block.Hash)
+	}
+
+	return block, nil
+}
+
+func ToBlockHeaderSubDocument(header utilities.BlockHeader) documents.BlockHeaderSubDocument {
+	return documents.BlockHeaderSubDocument{
+		Version:           header.Version,
+		Height:            header.Height,
+		PreviousHash:      header.PreviousHash,
+		MerkleRoot:        header.MerkleRoot,
+		TimeStamp:         header.TimeStamp,
+		Bits:              header.Bits,
+		Nonce:             header.Nonce,
+		BlockMinerAddress: header.Miner,
+		MinerPublicKey:    header.MinerPublicKey,
+		BlockSignature:    header.Signature,
+	}
+}
+
+func FromBlockHeaderSubDocument(header documents.BlockHeaderSubDocument) utilities.BlockHeader {
+	return utilities.BlockHeader{
+		Version:        header.Version,
+		Height:         header.Height,
+		PreviousHash:   header.PreviousHash,
+		MerkleRoot:     header.MerkleRoot,
+		TimeStamp:      header.TimeStamp,
+		Bits:           header.Bits,
+		Nonce:          header.Nonce,
+		Miner:          header.BlockMinerAddress,
+		MinerPublicKey: header.MinerPublicKey,
+		Signature:      header.BlockSignature,
+	}
+}
@@ -0,0 +1,144 @@
+package mappers
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	primitives "bitshare-chain/infrastructure/primitives"
+	utilities "bitshare-chain/infrastructure/utilities"
+	hex "encoding/hex"
+	errors "errors"
+	reflect "reflect"
+	strings "strings"
+	testing "testing"
+	time "time"
+)
+
+// Documents are hashed and sent through the canonical encoding of the block they map to. These vectors are
+// the ones of the utilities and primitives packages, stored documents must keep encoding to them.
+const (
+	goldenTransactionSubDocumentHex = "" +
+		"01" + "01" + // version, transaction tag
+		"00000000" + // kind: transfer
+		"00000006" + "73656e646572" + // from address: "sender"
+		"00000002" + "0203" + // public key
+		"00000009" + "726563697069656e74" + // to address: "recipient"
+		"0000000008f0d180" + // amount: 1.5 coins
+		"00000000000003e8" + // fee: 1000 units
+		"0000000000000007" + // nonce
+		"00000003" + "040506" // signature
+
+	goldenBlockDocumentHex = "" +
+		"01" + "02" + // version, block tag
+		"0000004d" +
+		"01" + "03" + // version, header tag
+		"00000001" + // header version
+		"0000000000000002" + // height
+		"00000008" + "70726576696f7573" + // previous hash: "previous"
+		"00000006" + "6d65726b6c65" + // merkle root: "merkle"
+		"0000018bcfe56800" + // time stamp: 1700000000000 ms
+		"1d00ffff" + // bits
+		"00000005" + "6d696e6572" + // miner: "miner"
+		"00000002" + "0207" + // miner public key
+		"000000000000002a" + // nonce
+		"00000002" + "0809" + // signature
+		"00000001" + // transaction count
+		"00000042" + goldenTransactionSubDocumentHex
+
+	goldenBlockDocumentHash = "9e84acbc89062ee32c3b470e8a3032177dd1906e91751120582bd64322dc17a9"
+)
+
+func goldenTransactionSubDocument() documents.TransactionSubDocument {
+	return documents.TransactionSubDocument{
+		Kind:        primitives.TransactionKindTransfer,
+		FromAddress: "sender",
+		PublicKey:   []byte{0x02, 0x03},
+		ToAddress:   "recipient",
+		Amount:      150_000_000,
+		Fee:         1000,
+		Nonce:       7,
+		Signature:   []byte{0x04, 0x05, 0x06},
+	}
+}
+
+func goldenBlockDocument() documents.BlockDocument {
+	return documents.BlockDocument{
+		Index: 2,
+		Hash:  goldenBlockDocumentHash,
+		Header: documents.BlockHeaderSubDocument{
+			Version:           1,
+			Height:            2,
+			PreviousHash:      "previous",
+			MerkleRoot:        "merkle",
+			TimeStamp:         time.UnixMilli(1_700_000_000_000).UTC(),
+			Bits:              0x1d00ffff,
+			Nonce:             42,
+			BlockMinerAddress: "miner",
+			MinerPublicKey:    []byte{0x02, 0x07},
+			BlockSignature:    []byte{0x08, 0x09},
+		},
+		Transactions: []documents.TransactionSubDocument{goldenTransactionSubDocument()},
+	}
+}
+
+func TestTransactionSubDocumentCanonicalEncodingGolden(t *testing.T) {
+	transactionDocument := goldenTransactionSubDocument()
+	transaction := FromTransactionSubDocument(transactionDocument)
+
+	encoded := hex.EncodeToString(transaction.MarshalCanonical())
+	if encoded != goldenTransactionSubDocumentHex {
+		t.Fatalf("encoding changed\n got: %s\nwant: %s", encoded, goldenTransactionSubDocumentHex)
+	}
+
+	data, _ := hex.DecodeString(goldenTransactionSubDocumentHex)
+	decoded, err := primitives.UnmarshalCanonicalTransaction(data)
+	if err != nil {
+		t.Fatalf("decoding the golden transaction failed: %v", err)
+	}
+	if roundTrip := ToTransactionSubDocument(decoded); !reflect.DeepEqual(roundTrip, transactionDocument) {
+		t.Fatalf("round trip changed the document\n got: %+v\nwant: %+v", roundTrip, transactionDocument)
+	}
+}
+
+func TestBlockDocumentCanonicalEncodingGolden(t *testing.T) {
+	blockDocument := goldenBlockDocument()
+	block, err := FromBlockDocument(blockDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := hex.EncodeToString(block.MarshalCanonical())
+	if encoded != goldenBlockDocumentHex {
+		t.Fatalf("encoding changed\n got: %s\nwant: %s", encoded, goldenBlockDocumentHex)
+	}
+
+	if hash := block.CalculateHash(); hash != goldenBlockDocumentHash {
+		t.Fatalf("hash changed\n got: %s\nwant: %s", hash, goldenBlockDocumentHash)
+	}
+
+	data, _ := hex.DecodeString(goldenBlockDocumentHex)
+	decoded, err := utilities.UnmarshalCanonicalBlock(data)
+	if err != nil {
+		t.Fatalf("decoding the golden block failed: %v", err)
+	}
+	if roundTrip := ToBlockDocument(decoded); !reflect.DeepEqual(*roundTrip, blockDocument) {
+		t.Fatalf("round trip changed the document\n got: %+v\nwant: %+v", *roundTrip, blockDocument)
+	}
+}
+
+func TestFromBlockDocumentRejectsHashMismatches(t *testing.T) {
+	tamper := map[string]func(blockDocument *documents.BlockDocument){
+		"stored hash":   func(blockDocument *documents.BlockDocument) { blockDocument.Hash = strings.Repeat("0", 64) },
+		"missing hash":  func(blockDocument *documents.BlockDocument) { blockDocument.Hash = "" },
+		"nonce":         func(blockDocument *documents.BlockDocument) { blockDocument.Header.Nonce++ },
+		"previous hash": func(blockDocument *documents.BlockDocument) { blockDocument.Header.PreviousHash = "other" },
+		"merkle root":   func(blockDocument *documents.BlockDocument) { blockDocument.Header.MerkleRoot = "other" },
+	}
+
+	for name, apply := range tamper {
+		blockDocument := goldenBlockDocument()
+		apply(&blockDocument)
+
+		if _, err := FromBlockDocument(blockDocument); !errors.Is(err, utilities.ErrInvalidBlockHeader) {
+			t.Fatalf("%s: got %v, want %v", name, err, utilities.ErrInvalidBlockHeader)
+		}
+	}
+}
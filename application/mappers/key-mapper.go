@@ -0,0 +1,13 @@
+package mappers
+
+import (
+	viewmodels "bitshare-chain/domain/view-models"
+	keystore "bitshare-chain/infrastructure/keystore"
+)
+
+func ToKeyVM(key keystore.KeyInfo) viewmodels.KeyVM {
+	return viewmodels.KeyVM{
+		KeyId:   key.Id,
+		Address: key.Address,
+	}
+}
@@ -0,0 +1,26 @@
+package mappers
+
+import (
+	viewmodels "bitshare-chain/domain/view-models"
+	utilities "bitshare-chain/infrastructure/utilities"
+	hex "encoding/hex"
+)
+
+func ToTransactionProofVM(proof *utilities.MerkleProof, block *utilities.Block, height int64) viewmodels.TransactionProofVM {
+	steps := make([]viewmodels.MerkleProofStepVM, 0, len(proof.Steps))
+	for _, step := range proof.Steps {
+		steps = append(steps, viewmodels.MerkleProofStepVM{
+			Hash:   hex.EncodeToString(step.Hash),
+			IsLeft: step.IsLeft,
+		})
+	}
+
+	return viewmodels.TransactionProofVM{
+		TransactionId: proof.TransactionId,
+		BlockHash:     block.Hash,
+		BlockHeight:   height,
+		MerkleRoot:    block.Header.MerkleRoot,
+		Index:         proof.Index,
+		Steps:         steps,
+	}
+}
@@ -0,0 +1,27 @@
+package mappers
+
+import (
+	viewmodels "bitshare-chain/domain/view-models"
+	mining "bitshare-chain/infrastructure/mining"
+)
+
+func ToMiningStatusVM(status mining.MiningStatus, running bool, minerAddress string, lastError error) viewmodels.MiningStatusVM {
+	miningStatusVM := viewmodels.MiningStatusVM{
+		Running:       running,
+		MinerAddress:  minerAddress,
+		Height:        status.Height,
+		Transactions:  status.Transactions,
+		Hashes:        status.Hashes,
+		HashRate:      status.HashRate,
+		Restarts:      status.Restarts,
+		BlocksMined:   status.BlocksMined,
+		LastBlockHash: status.LastBlockHash,
+		StartedAt:     status.StartedAt,
+	}
+
+	if lastError != nil {
+		miningStatusVM.LastError = lastError.Error()
+	}
+
+	return miningStatusVM
+}
@@ -0,0 +1,24 @@
+package mappers
+
+import (
+	viewmodels "bitshare-chain/domain/view-models"
+	utilities "bitshare-chain/infrastructure/utilities"
+)
+
+func ToSupplyVM(supply utilities.SupplyInfo) viewmodels.SupplyVM {
+	supplyVM := viewmodels.SupplyVM{
+		Height:        supply.Height,
+		IssuedSupply:  supply.IssuedSupply.String(),
+		CurrentReward: supply.CurrentReward.String(),
+	}
+
+	if supply.MaxSupply != 0 {
+		supplyVM.MaxSupply = supply.MaxSupply.String()
+	}
+
+	if supply.NextHalvingHeight >= 0 {
+		supplyVM.NextHalvingHeight = supply.NextHalvingHeight
+	}
+
+	return supplyVM
+}
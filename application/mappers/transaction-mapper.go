@@ -0,0 +1,74 @@
+package mappers
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	bindingmodels "bitshare-chain/domain/binding-models"
+	viewmodels "bitshare-chain/domain/view-models"
+	primitives "bitshare-chain/infrastructure/primitives"
+	hex "encoding/hex"
+)
+
+func ToTransactionSubDocument(transaction primitives.BlockTransaction) documents.TransactionSubDocument {
+	return documents.TransactionSubDocument{
+		Kind:        transaction.Kind,
+		FromAddress: transaction.FromAddress,
+		PublicKey:   transaction.PublicKey,
+		ToAddress:   transaction.ToAddress,
+		Amount:      transaction.Amount,
+		Fee:         transaction.Fee,
+		Nonce:       transaction.Nonce,
+		Signature:   transaction.Signature,
+	}
+}
+
+func FromTransactionSubDocument(transaction documents.TransactionSubDocument) primitives.BlockTransaction {
+	return primitives.BlockTransaction{
+		Kind:        transaction.Kind,
+		FromAddress: transaction.FromAddress,
+		PublicKey:   transaction.PublicKey,
+		ToAddress:   transaction.ToAddress,
+		Amount:      transaction.Amount,
+		Fee:         transaction.Fee,
+		Nonce:       transaction.Nonce,
+		Signature:   transaction.Signature,
+	}
+}
+
+func ToTransactionBindingModel(transaction primitives.BlockTransaction) bindingmodels.TransactionBindingModel {
+	return bindingmodels.TransactionBindingModel{
+		FromAddress: transaction.FromAddress,
+		PublicKey:   transaction.PublicKey,
+		ToAddress:   transaction.ToAddress,
+		Amount:      transaction.Amount,
+		Fee:         transaction.Fee,
+		Nonce:       transaction.Nonce,
+		Signature:   transaction.Signature,
+	}
+}
+
+func FromTransactionBindingModel(transaction bindingmodels.TransactionBindingModel) primitives.BlockTransaction {
+	return primitives.BlockTransaction{
+		FromAddress: transaction.FromAddress,
+		PublicKey:   transaction.PublicKey,
+		ToAddress:   transaction.ToAddress,
+		Amount:      transaction.Amount,
+		Fee:         transaction.Fee,
+		Nonce:       transaction.Nonce,
+		Signature:   transaction.Signature,
+	}
+}
+
+func ToUnsignedTransactionVM(transaction primitives.BlockTransaction, minimumFee primitives.Amount) viewmodels.UnsignedTransactionVM {
+	return viewmodels.UnsignedTransactionVM{
+		FromAddress:     transaction.FromAddress,
+		PublicKey:       transaction.PublicKey,
+		ToAddress:       transaction.ToAddress,
+		Amount:          transaction.Amount,
+		Fee:             transaction.Fee,
+		MinimumFee:      minimumFee,
+		Nonce:           transaction.Nonce,
+		EncodingVersion: primitives.CanonicalEncodingVersion,
+		SigningBytes:    hex.EncodeToString(transaction.SigningBytes()),
+		SigningHash:     hex.EncodeToString(transaction.CalculateHash()),
+	}
+}
@@ -0,0 +1,182 @@
+package mappers
+
+import (
+	bindingmodels "bitshare-chain/domain/binding-models"
+	mempool "bitshare-chain/infrastructure/mempool"
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	utilities "bitshare-chain/infrastructure/utilities"
+	bytes "bytes"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	json "encoding/json"
+	testing "testing"
+	time "time"
+)
+
+// easyBits is a target half of all hashes meet, so blocks mine at once.
+const easyBits uint32 = 0x207fffff
+
+type chainState map[string]utilities.AccountState
+
+func (chain chainState) GetAccountState(address string) utilities.AccountState {
+	return chain[address]
+}
+
+func (chain chainState) GetMatureBalance(address string) primitives.Amount {
+	return chain[address].Balance
+}
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, primitives.PublicKeyToAddress(&key.PublicKey)
+}
+
+// receiveTransaction passes a transaction through the API layer: the JSON binding model a signer posts and
+// the mapper the controllers use.
+func receiveTransaction(t *testing.T, transaction primitives.BlockTransaction) primitives.BlockTransaction {
+	t.Helper()
+
+	body, err := json.Marshal(ToTransactionBindingModel(transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindingModel := bindingmodels.TransactionBindingModel{}
+	if err := json.Unmarshal(body, &bindingModel); err != nil {
+		t.Fatal(err)
+	}
+	return FromTransactionBindingModel(bindingModel)
+}
+
+func TestTransactionSignedThroughTheApiVerifiesInAMinedBlock(t *testing.T) {
+	senderKey, sender := newTestKey(t)
+	_, recipient := newTestKey(t)
+	minerKey, miner := newTestKey(t)
+
+	transactions := []primitives.BlockTransaction{}
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		transaction := primitives.NewBlockTransaction(sender, recipient, 25*primitives.AmountUnitsPerCoin, 1000, nonce)
+		if err := transaction.SignTransaction(senderKey); err != nil {
+			t.Fatal(err)
+		}
+
+		received := receiveTransaction(t, *transaction)
+		if err := received.Verify(); err != nil {
+			t.Fatalf("transaction %d does not verify after the API: %v", nonce, err)
+		}
+		transactions = append(transactions, received)
+	}
+
+	chain := chainState{sender: {Address: sender, Balance: 100 * primitives.AmountUnitsPerCoin}}
+	pool := mempool.NewMempool(settings.MempoolOptions{
+		MaxTransactions:          10,
+		MaxTransactionsPerSender: 10,
+		TransactionExpiry:        time.Hour,
+		MinRelayFeePerKb:         1000,
+	}, chain)
+	for _, transaction := range transactions {
+		if err := pool.Add(transaction); err != nil {
+			t.Fatalf("mempool rejected a transaction signed through the API: %v", err)
+		}
+	}
+
+	selected := pool.SelectTransactions(10)
+	if len(selected) != len(transactions) {
+		t.Fatalf("selected %d of %d transactions", len(selected), len(transactions))
+	}
+
+	coinbase, err := utilities.NewCoinbaseTransaction(1, miner, 50*primitives.AmountUnitsPerCoin, selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := utilities.NewBlock(1, time.Now().UTC(), append([]primitives.BlockTransaction{coinbase}, selected...), "parent", miner, easyBits)
+	if err := block.MineBlock(context.Background(), minerKey, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !block.HasValidTransactions() {
+		t.Fatal("a transaction signed through the API does not verify in the mined block")
+	}
+	if !block.HasValidMerkleRoot() {
+		t.Fatal("the merkle root does not commit to the transactions")
+	}
+	if err := block.Header.VerifySignature(); err != nil {
+		t.Fatalf("the mined header does not verify: %v", err)
+	}
+
+	// A transaction changed after signing must not verify, whatever layer changed it.
+	tampered := block.Transactions[1]
+	tampered.Amount++
+	block.Transactions[1] = receiveTransaction(t, tampered)
+	if block.HasValidTransactions() {
+		t.Fatal("a tampered transaction verifies in the block")
+	}
+}
+
+func TestTransactionDocumentRoundTripKeepsHashAndSignature(t *testing.T) {
+	senderKey, sender := newTestKey(t)
+	_, recipient := newTestKey(t)
+
+	transaction := primitives.NewBlockTransaction(sender, recipient, 3*primitives.AmountUnitsPerCoin+1, 1000, 4)
+	if err := transaction.SignTransaction(senderKey); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := FromTransactionSubDocument(ToTransactionSubDocument(*transaction))
+
+	if !bytes.Equal(stored.CalculateHash(), transaction.CalculateHash()) {
+		t.Fatal("the signing hash changed in the document round trip")
+	}
+	if stored.TransactionId() != transaction.TransactionId() {
+		t.Fatal("the transaction id changed in the document round trip")
+	}
+	if !bytes.Equal(stored.Signature, transaction.Signature) || !bytes.Equal(stored.PublicKey, transaction.PublicKey) {
+		t.Fatal("the signature or public key changed in the document round trip")
+	}
+	if err := stored.Verify(); err != nil {
+		t.Fatalf("the stored transaction does not verify: %v", err)
+	}
+}
+
+func TestBlockDocumentRoundTripKeepsHashAndSignatures(t *testing.T) {
+	senderKey, sender := newTestKey(t)
+	_, recipient := newTestKey(t)
+	minerKey, miner := newTestKey(t)
+
+	transaction := primitives.NewBlockTransaction(sender, recipient, primitives.AmountUnitsPerCoin, 1000, 0)
+	if err := transaction.SignTransaction(senderKey); err != nil {
+		t.Fatal(err)
+	}
+
+	block := utilities.NewBlock(1, time.Now().UTC(), []primitives.BlockTransaction{*transaction}, "parent", miner, easyBits)
+	if err := block.MineBlock(context.Background(), minerKey, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := FromBlockDocument(*ToBlockDocument(*block))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Hash != block.Hash || stored.CalculateHash() != block.Hash {
+		t.Fatal("the block hash changed in the document round trip")
+	}
+	if !bytes.Equal(stored.MarshalCanonical(), block.MarshalCanonical()) {
+		t.Fatal("the canonical encoding changed in the document round trip")
+	}
+	if err := stored.Header.VerifySignature(); err != nil {
+		t.Fatalf("the stored header does not verify: %v", err)
+	}
+	if !stored.HasValidTransactions() || !stored.HasValidMerkleRoot() {
+		t.Fatal("the stored transactions do not verify")
+	}
+}
@@ -0,0 +1,35 @@
+package mappers
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	viewmodels "bitshare-chain/domain/view-models"
+	keystore "bitshare-chain/infrastructure/keystore"
+)
+
+func ToWalletAccountVM(walletAccount documents.WalletAccountDocument) viewmodels.WalletAccountVM {
+	addresses := []viewmodels.WalletAddressVM{}
+	for _, walletAddress := range walletAccount.Addresses {
+		addresses = append(addresses, viewmodels.WalletAddressVM{
+			Index:   walletAddress.Index,
+			Path:    walletAddress.Path,
+			Address: walletAddress.Address,
+			KeyId:   walletAddress.KeyId,
+		})
+	}
+
+	return viewmodels.WalletAccountVM{
+		WalletId:    walletAccount.ID.Hex(),
+		AccountPath: walletAccount.AccountPath,
+		Address:     walletAccount.Address,
+		Addresses:   addresses,
+	}
+}
+
+func ToWalletAddressSubDocument(index uint32, key keystore.KeyInfo) documents.WalletAddressSubDocument {
+	return documents.WalletAddressSubDocument{
+		Index:   index,
+		Path:    key.Path,
+		Address: key.Address,
+		KeyId:   key.Id,
+	}
+}
@@ -1 +1,145 @@
 package background_services
+
+import (
+	mappers "bitshare-chain/application/mappers"
+	services "bitshare-chain/application/services"
+	viewmodels "bitshare-chain/domain/view-models"
+	primitives "bitshare-chain/infrastructure/primitives"
+	utilities "bitshare-chain/infrastructure/utilities"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	errors "errors"
+	fmt "fmt"
+	sync "sync"
+	time "time"
+)
+
+// MiningRetryDelay is how long the service waits before it mines again after the miner failed.
+const MiningRetryDelay = 5 * time.Second
+
+var (
+	ErrMiningAlreadyRunning = errors.New("mining is already running")
+	ErrNoSigningKey         = errors.New("no block signing key is unlocked")
+)
+
+// MiningBackgroundService keeps mining blocks from the mempool with the node's block signing keys. Mined blocks
+// are stored by the chain itself and broadcast to the connected nodes.
+type MiningBackgroundService struct {
+	blockchainService     *services.BlockchainService
+	blockBroadcastService *services.BlockBroadcastService
+
+	mutex        sync.Mutex
+	cancel       context.CancelFunc
+	done         chan struct{}
+	minerAddress string
+	lastError    error
+	broadcasts   sync.WaitGroup
+}
+
+func NewMiningBackgroundService(blockchainService *services.BlockchainService, blockBroadcastService *services.BlockBroadcastService) *MiningBackgroundService {
+	return &MiningBackgroundService{
+		blockchainService:     blockchainService,
+		blockBroadcastService: blockBroadcastService,
+	}
+}
+
+// Start begins mining with the signing key, the rewards are paid to the key's address.
+func (service *MiningBackgroundService) Start(signingKey *ecdsa.PrivateKey) error {
+	if signingKey == nil {
+		return ErrNoSigningKey
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if service.cancel != nil {
+		return ErrMiningAlreadyRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service.cancel = cancel
+	service.done = make(chan struct{})
+	service.minerAddress = primitives.PublicKeyToAddress(&signingKey.PublicKey)
+	service.lastError = nil
+
+	go service.run(ctx, signingKey, service.minerAddress, service.done)
+	return nil
+}
+
+// Restart stops a running miner and starts it again with the signing key, e.g. after the keys changed.
+func (service *MiningBackgroundService) Restart(signingKey *ecdsa.PrivateKey) error {
+	if err := service.Shutdown(context.Background()); err != nil {
+		return err
+	}
+
+	return service.Start(signingKey)
+}
+
+// Shutdown stops mining and waits for the miner and the pending broadcasts until ctx is done.
+func (service *MiningBackgroundService) Shutdown(ctx context.Context) error {
+	service.mutex.Lock()
+	cancel, done := service.cancel, service.done
+	service.cancel, service.done = nil, nil
+	service.mutex.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (service *MiningBackgroundService) Status() viewmodels.MiningStatusVM {
+	service.mutex.Lock()
+	running, minerAddress, lastError := service.cancel != nil, service.minerAddress, service.lastError
+	service.mutex.Unlock()
+
+	return mappers.ToMiningStatusVM(service.blockchainService.MiningStatus(), running, minerAddress, lastError)
+}
+
+func (service *MiningBackgroundService) run(ctx context.Context, signingKey *ecdsa.PrivateKey, minerAddress string, done chan struct{}) {
+	defer close(done)
+	defer service.broadcasts.Wait()
+
+	for {
+		err := service.blockchainService.RunMiner(ctx, minerAddress, signingKey, func(block utilities.Block) {
+			service.broadcast(ctx, block)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		service.setLastError(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(MiningRetryDelay):
+		}
+	}
+}
+
+// broadcast sends the block without holding up the miner. A failed broadcast shows up in the status.
+func (service *MiningBackgroundService) broadcast(ctx context.Context, block utilities.Block) {
+	service.broadcasts.Add(1)
+	go func() {
+		defer service.broadcasts.Done()
+
+		if err := service.blockBroadcastService.BroadcastBlock(ctx, block); err != nil {
+			service.setLastError(fmt.Errorf("failed to broadcast block %s: %v", block.Hash, err))
+		}
+	}()
+}
+
+func (service *MiningBackgroundService) setLastError(err error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.lastError = err
+}
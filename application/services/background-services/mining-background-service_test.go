@@ -0,0 +1,20 @@
+package background_services
+
+import (
+	errors "errors"
+	testing "testing"
+)
+
+func TestStartRejectsAMissingSigningKey(t *testing.T) {
+	service := NewMiningBackgroundService(nil, nil)
+
+	if err := service.Start(nil); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("starting without a key returned %v, want %v", err, ErrNoSigningKey)
+	}
+	if err := service.Restart(nil); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("restarting without a key returned %v, want %v", err, ErrNoSigningKey)
+	}
+	if service.cancel != nil {
+		t.Fatal("the miner runs without a key")
+	}
+}
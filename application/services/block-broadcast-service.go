@@ -0,0 +1,77 @@
+package services
+
+import (
+	repositories "bitshare-chain/application/data-access/repositories"
+	utilities "bitshare-chain/infrastructure/utilities"
+	bytes "bytes"
+	context "context"
+	errors "errors"
+	fmt "fmt"
+	http "net/http"
+	strings "strings"
+	time "time"
+)
+
+// ReceiveBlockPath is the endpoint every node accepts canonically encoded blocks on.
+const ReceiveBlockPath = "/api/receive-block"
+
+// BlockBroadcastTimeout bounds the request to a single node.
+const BlockBroadcastTimeout = 10 * time.Second
+
+type BlockBroadcastService struct {
+	nodeMetadataRepository repositories.NodeMetadataRepository
+	httpClient             *http.Client
+}
+
+func NewBlockBroadcastService(nodeMetadataRepository repositories.NodeMetadataRepository) *BlockBroadcastService {
+	return &BlockBroadcastService{
+		nodeMetadataRepository: nodeMetadataRepository,
+		httpClient:             &http.Client{Timeout: BlockBroadcastTimeout},
+	}
+}
+
+// BroadcastBlock sends the block to every connected node. A node that cannot be reached does not stop the
+// broadcast, the returned error lists every node that failed.
+func (service *BlockBroadcastService) BroadcastBlock(ctx context.Context, block utilities.Block) error {
+	nodeConnections, err := service.nodeMetadataRepository.GetNodeConnections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load the node connections: %v", err)
+	}
+
+	encodedBlock := block.MarshalCanonical()
+	var errs []error
+
+	for _, nodeConnection := range nodeConnections {
+		if nodeConnection.NodeURL == "" {
+			continue
+		}
+
+		if err := service.sendBlock(ctx, nodeConnection.NodeURL, encodedBlock); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %v", nodeConnection.NodeID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (service *BlockBroadcastService) sendBlock(ctx context.Context, nodeURL string, encodedBlock []byte) error {
+	url := strings.TrimRight(nodeURL, "/") + ReceiveBlockPath
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encodedBlock))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	response, err := service.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// A node that already knows the block answers with a conflict, which is fine for a broadcast.
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusConflict {
+		return fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	return nil
+}
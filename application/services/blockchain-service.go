@@ -1 +1,245 @@
 package services
+
+import (
+	documents "bitshare-chain/application/data-access/documents"
+	repositories "bitshare-chain/application/data-access/repositories"
+	mappers "bitshare-chain/application/mappers"
+	viewmodels "bitshare-chain/domain/view-models"
+	mempool "bitshare-chain/infrastructure/mempool"
+	mining "bitshare-chain/infrastructure/mining"
+	primitives "bitshare-chain/infrastructure/primitives"
+	settings "bitshare-chain/infrastructure/settings"
+	utilities "bitshare-chain/infrastructure/utilities"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	errors "errors"
+	fmt "fmt"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found in the chain")
+
+// BlockchainService owns the node's chain and mempool and acts as the chain's Mongo backed block and account state store.
+type BlockchainService struct {
+	blockchain             *utilities.Blockchain
+	mempool                *mempool.Mempool
+	miner                  *mining.Miner
+	mempoolOptions         settings.MempoolOptions
+	miningOptions          settings.MiningOptions
+	blockchainRepository   repositories.BlockchainRepository
+	accountStateRepository repositories.AccountStateRepository
+}
+
+func NewBlockchainService(blockchainRepository repositories.BlockchainRepository, accountStateRepository repositories.AccountStateRepository, mempoolOptions settings.MempoolOptions, miningOptions settings.MiningOptions) *BlockchainService {
+	return &BlockchainService{
+		mempoolOptions:         mempoolOptions,
+		miningOptions:          miningOptions,
+		blockchainRepository:   blockchainRepository,
+		accountStateRepository: accountStateRepository,
+	}
+}
+
+// LoadBlockchain restores the chain from the database. It has to be called once at startup and
+// fails when the stored genesis block was not created from the given spec.
+func (service *BlockchainService) LoadBlockchain(genesisSpec *settings.GenesisSpec) (*utilities.Blockchain, error) {
+	blockchain, err := utilities.NewBlockchainFromStore(genesisSpec, service, service)
+	if err != nil {
+		return nil, err
+	}
+
+	service.blockchain = blockchain
+	service.mempool = mempool.NewMempool(service.mempoolOptions, blockchain)
+
+	// Mined transactions leave the mempool with every new block, orphaned ones come back after a reorg.
+	blockchain.SubscribeBlocks(func(block utilities.Block) {
+		service.mempool.Revalidate()
+	})
+	blockchain.SubscribeReorgs(func(event utilities.ReorgEvent) {
+		service.mempool.Revalidate(event.Orphaned...)
+	})
+
+	// Subscribed after the mempool, so a restarted template never contains transactions of the new tip.
+	service.miner = mining.NewMiner(blockchain, service.mempool, service.miningOptions)
+
+	return blockchain, nil
+}
+
+func (service *BlockchainService) Blockchain() *utilities.Blockchain {
+	return service.blockchain
+}
+
+func (service *BlockchainService) Mempool() *mempool.Mempool {
+	return service.mempool
+}
+
+func (service *BlockchainService) AddTransaction(transaction primitives.BlockTransaction) error {
+	return service.mempool.Add(transaction)
+}
+
+func (service *BlockchainService) GetPendingTransactions() []primitives.BlockTransaction {
+	return service.mempool.Pending()
+}
+
+func (service *BlockchainService) GetNextNonce(address string) uint64 {
+	return service.mempool.NextNonce(address)
+}
+
+// BuildTransaction prepares a transfer for offline signing with the sender's next nonce. The public key may be
+// given in any encoding, the transaction carries it compressed. A nil fee is replaced by the minimum relay fee.
+func (service *BlockchainService) BuildTransaction(fromAddress string, publicKeyBytes []byte, toAddress string, amount primitives.Amount, fee *primitives.Amount) (viewmodels.UnsignedTransactionVM, error) {
+	publicKey, err := primitives.ConvertFromBytes(publicKeyBytes)
+	if err != nil {
+		return viewmodels.UnsignedTransactionVM{}, err
+	}
+
+	if primitives.PublicKeyToAddress(&publicKey) != fromAddress {
+		return viewmodels.UnsignedTransactionVM{}, fmt.Errorf("%w: public key does not belong to %s", primitives.ErrInvalidAddress, fromAddress)
+	}
+
+	transaction := primitives.NewBlockTransaction(fromAddress, toAddress, amount, 0, service.mempool.NextNonce(fromAddress))
+	transaction.PublicKey = primitives.MarshalPublicKey(&publicKey)
+
+	// The encoding has a fixed size whatever the fee, so the minimum fee does not change once it is set.
+	minimumFee := service.mempool.MinimumFee(transaction.SignedSize())
+	transaction.Fee = minimumFee
+	if fee != nil {
+		transaction.Fee = *fee
+	}
+
+	return mappers.ToUnsignedTransactionVM(*transaction, minimumFee), nil
+}
+
+// SubmitTransaction verifies a transaction signed outside the node and adds it to the mempool.
+func (service *BlockchainService) SubmitTransaction(transaction primitives.BlockTransaction) (string, error) {
+	if err := transaction.Verify(); err != nil {
+		return "", err
+	}
+
+	if err := service.mempool.Add(transaction); err != nil {
+		return "", err
+	}
+	return transaction.TransactionId(), nil
+}
+
+// IsAddressUsed reports whether the address sent or received anything on the chain.
+func (service *BlockchainService) IsAddressUsed(address string) bool {
+	return service.blockchain.GetAccountState(address).LastActivityHeight >= 0
+}
+
+// MineBlock mines the best mempool transactions into a new block on top of the current tip. The template
+// is rebuilt whenever the tip or the mempool changes, mining stops once ctx is cancelled.
+func (service *BlockchainService) MineBlock(ctx context.Context, miningRewardAddress string, signingKey *ecdsa.PrivateKey) (utilities.Block, error) {
+	block, err := service.miner.MineBlock(ctx, miningRewardAddress, signingKey)
+	if err != nil {
+		return utilities.Block{}, err
+	}
+
+	return *block, nil
+}
+
+// RunMiner mines blocks on top of the tip until ctx is cancelled, see mining.Miner.Run.
+func (service *BlockchainService) RunMiner(ctx context.Context, miningRewardAddress string, signingKey *ecdsa.PrivateKey, mined func(block utilities.Block)) error {
+	return service.miner.Run(ctx, miningRewardAddress, signingKey, mined)
+}
+
+func (service *BlockchainService) MiningStatus() mining.MiningStatus {
+	return service.miner.Status()
+}
+
+// AddBlock accepts a block mined by another node.
+func (service *BlockchainService) AddBlock(block utilities.Block) error {
+	return service.blockchain.AddBlock(block)
+}
+
+func (service *BlockchainService) GetSupply() (viewmodels.SupplyVM, error) {
+	supply, err := service.blockchain.GetSupply()
+	if err != nil {
+		return viewmodels.SupplyVM{}, err
+	}
+
+	return mappers.ToSupplyVM(supply), nil
+}
+
+func (service *BlockchainService) GetTransactionProof(transactionId string) (viewmodels.TransactionProofVM, error) {
+	block, height, ok := service.blockchain.FindTransaction(transactionId)
+	if !ok {
+		return viewmodels.TransactionProofVM{}, ErrTransactionNotFound
+	}
+
+	proof, err := block.GetTransactionProof(transactionId)
+	if err != nil {
+		return viewmodels.TransactionProofVM{}, err
+	}
+
+	return mappers.ToTransactionProofVM(proof, block, height), nil
+}
+
+func (service *BlockchainService) LoadBlocks() ([]utilities.Block, error) {
+	ctx := context.Background()
+
+	tip, err := service.blockchainRepository.GetTip(ctx)
+	if err == repositories.ErrBlockNotFound {
+		return []utilities.Block{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	blockDocuments, err := service.blockchainRepository.GetBlocksInRange(ctx, 0, tip.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]utilities.Block, 0, len(blockDocuments))
+	for _, blockDocument := range blockDocuments {
+		block, err := mappers.FromBlockDocument(blockDocument)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+func (service *BlockchainService) AppendBlock(block utilities.Block) error {
+	return service.blockchainRepository.AppendBlock(context.Background(), mappers.ToBlockDocument(block))
+}
+
+func (service *BlockchainService) RemoveBlocksAbove(height int64) error {
+	return service.blockchainRepository.DeleteBlocksAbove(context.Background(), height)
+}
+
+func (service *BlockchainService) LoadAccountStates() ([]utilities.AccountState, utilities.AppliedTip, error) {
+	ctx := context.Background()
+
+	accountStateDocuments, err := service.accountStateRepository.GetAllAccountStates(ctx)
+	if err != nil {
+		return nil, utilities.AppliedTip{}, err
+	}
+
+	appliedTip := utilities.AppliedTip{}
+	tipDocument, err := service.accountStateRepository.GetAppliedTip(ctx)
+	if err == nil {
+		appliedTip = utilities.AppliedTip{Height: tipDocument.Height, Hash: tipDocument.Hash}
+	} else if err != repositories.ErrAppliedTipNotFound {
+		return nil, utilities.AppliedTip{}, err
+	}
+
+	accountStates := make([]utilities.AccountState, 0, len(accountStateDocuments))
+	for _, accountStateDocument := range accountStateDocuments {
+		accountStates = append(accountStates, mappers.FromAccountStateDocument(accountStateDocument))
+	}
+
+	return accountStates, appliedTip, nil
+}
+
+func (service *BlockchainService) SaveAccountStates(updated []utilities.AccountState, removed []string, tip utilities.AppliedTip) error {
+	accountStateDocuments := make([]documents.AccountStateDocument, 0, len(updated))
+	for _, accountState := range updated {
+		accountStateDocuments = append(accountStateDocuments, mappers.ToAccountStateDocument(accountState))
+	}
+
+	tipDocument := documents.AppliedTipDocument{Height: tip.Height, Hash: tip.Hash}
+	return service.accountStateRepository.SaveAccountStates(context.Background(), accountStateDocuments, removed, tipDocument)
+}
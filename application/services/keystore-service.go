@@ -0,0 +1,116 @@
+package services
+
+import (
+	mappers "bitshare-chain/application/mappers"
+	viewmodels "bitshare-chain/domain/view-models"
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
+	keystore "bitshare-chain/infrastructure/keystore"
+	primitives "bitshare-chain/infrastructure/primitives"
+	hex "encoding/hex"
+	fmt "fmt"
+	time "time"
+)
+
+// KeystoreService exposes the node's keystore by key id, no private key is ever returned.
+type KeystoreService struct {
+	keyStore *keystore.KeyStore
+}
+
+func NewKeystoreService(keyStore *keystore.KeyStore) *KeystoreService {
+	return &KeystoreService{
+		keyStore: keyStore,
+	}
+}
+
+// CreateKey generates a key encrypted with the passphrase and returns its id and public key.
+func (service *KeystoreService) CreateKey(passphrase string) (viewmodels.WalletKeysVM, error) {
+	key, err := service.keyStore.NewKey(passphrase)
+	if err != nil {
+		return viewmodels.WalletKeysVM{}, err
+	}
+
+	publicKey := hex.EncodeToString(primitives.MarshalPublicKey(key.PublicKey))
+	return viewmodels.NewWalletKeysVM(key.Id, publicKey, key.Address, ""), nil
+}
+
+// ImportKey moves a hex encoded private key into the keystore.
+func (service *KeystoreService) ImportKey(privateKeyHex string, passphrase string) (viewmodels.KeyVM, error) {
+	privateKey, err := keystore.ParsePrivateKeyHex(privateKeyHex)
+	if err != nil {
+		return viewmodels.KeyVM{}, err
+	}
+
+	key, err := service.keyStore.ImportKey(privateKey, passphrase)
+	if err != nil {
+		return viewmodels.KeyVM{}, err
+	}
+	return mappers.ToKeyVM(key), nil
+}
+
+// CreateHDKeys stores the seed encrypted with the passphrase and derives the keys at the paths from it.
+func (service *KeystoreService) CreateHDKeys(seed []byte, passphrase string, paths []hdwallet.DerivationPath) (string, []keystore.KeyInfo, error) {
+	seedKey, err := service.keyStore.ImportSeed(seed, passphrase)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Without its keys the seed belongs to no wallet account, so it goes again.
+	keys, err := service.keyStore.AddDerivedKeys(seedKey.Id, passphrase, paths)
+	if err != nil {
+		if deleteErr := service.keyStore.DeleteSeed(seedKey.Id); deleteErr != nil {
+			return "", nil, fmt.Errorf("%w, and seed %s could not be removed: %v", err, seedKey.Id, deleteErr)
+		}
+		return "", nil, err
+	}
+	return seedKey.Id, keys, nil
+}
+
+// DeriveHDKey derives the key at path from a stored seed, unlocking the seed with its passphrase.
+func (service *KeystoreService) DeriveHDKey(seedKeyId string, passphrase string, path hdwallet.DerivationPath) (keystore.KeyInfo, error) {
+	keys, err := service.keyStore.AddDerivedKeys(seedKeyId, passphrase, []hdwallet.DerivationPath{path})
+	if err != nil {
+		return keystore.KeyInfo{}, err
+	}
+	return keys[0], nil
+}
+
+// DeleteHDKey removes a key derived from a seed, the seed and its other keys stay.
+func (service *KeystoreService) DeleteHDKey(keyId string) error {
+	return service.keyStore.DeleteDerivedKey(keyId)
+}
+
+// DeleteHDKeys removes a seed and every key derived from it.
+func (service *KeystoreService) DeleteHDKeys(seedKeyId string) error {
+	return service.keyStore.DeleteSeed(seedKeyId)
+}
+
+func (service *KeystoreService) GetKeys() ([]viewmodels.KeyVM, error) {
+	keys, err := service.keyStore.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	keyVMs := []viewmodels.KeyVM{}
+	for _, key := range keys {
+		keyVMs = append(keyVMs, mappers.ToKeyVM(key))
+	}
+	return keyVMs, nil
+}
+
+// UnlockKey makes the key usable for signing for timeout, zero means the keystore's default unlock timeout.
+func (service *KeystoreService) UnlockKey(keyId string, passphrase string, timeout time.Duration) (viewmodels.KeyVM, error) {
+	key, err := service.keyStore.Unlock(keyId, passphrase, timeout)
+	if err != nil {
+		return viewmodels.KeyVM{}, err
+	}
+	return mappers.ToKeyVM(key), nil
+}
+
+func (service *KeystoreService) LockKey(keyId string) {
+	service.keyStore.Lock(keyId)
+}
+
+// SignTransaction signs the transaction with the unlocked key, which must own the sender address.
+func (service *KeystoreService) SignTransaction(keyId string, transaction *primitives.BlockTransaction) error {
+	return service.keyStore.SignTransaction(keyId, transaction)
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	hdwallet "bitshare-chain/infrastructure/hdwallet"
+	keystore "bitshare-chain/infrastructure/keystore"
+	settings "bitshare-chain/infrastructure/settings"
+	bytes "bytes"
+	os "os"
+	testing "testing"
+)
+
+func TestCreateHDKeysRemovesTheSeedWhenAKeyFails(t *testing.T) {
+	directory := t.TempDir()
+	keyStore, err := keystore.NewKeyStore(settings.KeystoreOptions{Directory: directory, ScryptN: keystore.LightScryptN, ScryptP: keystore.LightScryptP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewKeystoreService(keyStore)
+
+	// Paths deeper than 255 levels cannot be derived.
+	paths := []hdwallet.DerivationPath{hdwallet.AccountPath(0).Child(0), make(hdwallet.DerivationPath, 256)}
+	if _, _, err := service.CreateHDKeys(bytes.Repeat([]byte{1}, 64), "passphrase", paths); err == nil {
+		t.Fatal("a path deeper than 255 levels was derived")
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("%d key files were left behind", len(entries))
+	}
+}
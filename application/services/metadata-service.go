@@ -4,61 +4,57 @@ import (
 	repositories "bitshare-chain/application/data-access/repositories"
 	enums "bitshare-chain/domain/enums"
 	viewmodels "bitshare-chain/domain/view-models"
-	services "bitshare-chain/infrastructure/utilities"
+	keystore "bitshare-chain/infrastructure/keystore"
+	primitives "bitshare-chain/infrastructure/primitives"
 	ecdsa "crypto/ecdsa"
-	elliptic "crypto/elliptic"
-	"crypto/rand"
 	hex "encoding/hex"
-	big "math/big"
 	sync "sync"
 )
 
 type MetadataService struct {
 	cache                  sync.Map
-	keyGenerator           services.KeyGenerator
+	keyStore               *keystore.KeyStore
 	nodeMetadataRepository repositories.NodeMetadataRepository
 }
 
-func NewMetadataService(keyGenerator services.KeyGenerator, nodeMetadataRepository repositories.NodeMetadataRepository) *MetadataService {
+func NewMetadataService(keyStore *keystore.KeyStore, nodeMetadataRepository repositories.NodeMetadataRepository) *MetadataService {
 	return &MetadataService{
-		keyGenerator:           keyGenerator,
+		keyStore:               keyStore,
 		nodeMetadataRepository: nodeMetadataRepository,
 	}
 }
 
-func (service *MetadataService) CreateOrUpdateKeys(privateKeyHex string) (viewmodels.WalletKeysVM, error) {
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+// SetBlockSigningKey unlocks the keystore key for the miner until the node stops or another key is set, and
+// records the key's address as the node's reward address.
+func (service *MetadataService) SetBlockSigningKey(keyId string, passphrase string) (viewmodels.WalletKeysVM, error) {
+	key, err := service.keyStore.Unlock(keyId, passphrase, -1)
 	if err != nil {
 		return viewmodels.WalletKeysVM{}, err
 	}
 
-	keys := &ecdsa.PrivateKey{}
-	keys.Curve = elliptic.P256()
-	keys.D = new(big.Int).SetBytes(privateKeyBytes)
+	if previousKeyId, ok := service.cache.Swap(enums.MinerKeyId, keyId); ok && previousKeyId != keyId {
+		service.keyStore.Lock(previousKeyId.(string))
+	}
 
-	random := rand.Reader
-	_, x, y, err := elliptic.GenerateKey(keys.Curve, random)
+	nodeId, err := service.nodeMetadataRepository.InsertMetadataRewardAddress(key.Address)
 	if err != nil {
 		return viewmodels.WalletKeysVM{}, err
 	}
 
-	keys.PublicKey = ecdsa.PublicKey{Curve: keys.Curve, X: x, Y: y}
-
-	publicKeyExport := elliptic.Marshal(keys.Curve, keys.PublicKey.X, keys.PublicKey.Y)
-
-	publicKey := hex.EncodeToString(publicKeyExport)
-	privateKeysExport := hex.EncodeToString(privateKeyBytes)
+	publicKey := hex.EncodeToString(primitives.MarshalPublicKey(key.PublicKey))
+	return viewmodels.NewWalletKeysVM(key.Id, publicKey, key.Address, nodeId), nil
+}
 
-	service.cache.Store(enums.MinerPrivateKey, privateKeysExport)
+// GetBlockSigningKey returns the key set through SetBlockSigningKey since the node started.
+func (service *MetadataService) GetBlockSigningKey() (*ecdsa.PrivateKey, bool) {
+	keyId, ok := service.cache.Load(enums.MinerKeyId)
+	if !ok {
+		return nil, false
+	}
 
-	nodeId, err := service.nodeMetadataRepository.InsertMetadataPublicKey(publicKey)
+	signingKey, err := service.keyStore.SigningKey(keyId.(string))
 	if err != nil {
-		return viewmodels.WalletKeysVM{}, err
+		return nil, false
 	}
-
-	return viewmodels.WalletKeysVM{
-		PublicKey:  publicKey,
-		PrivateKey: privateKeysExport,
-		NodeId:     nodeId,
-	}, nil
+	return signingKey, true
 }
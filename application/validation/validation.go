@@ -1,19 +1,33 @@
 package validation
 
 import (
+	primitives "bitshare-chain/infrastructure/primitives"
+
 	"github.com/go-playground/validator/v10"
 )
 
+// AddressTag validates that a string field holds a checksummed address, e.g. `validate:"required,address"`.
+const AddressTag = "address"
+
 type Validator struct {
 	validator *validator.Validate
 }
 
 func NewValidator() *Validator {
+	validate := validator.New()
+	if err := validate.RegisterValidation(AddressTag, isAddress); err != nil {
+		panic(err)
+	}
+
 	return &Validator{
-		validator: validator.New(),
+		validator: validate,
 	}
 }
 
 func (validator *Validator) ValidateStruct(structToBeValidated interface{}) error {
 	return validator.validator.Struct(structToBeValidated)
 }
+
+func isAddress(field validator.FieldLevel) bool {
+	return primitives.ValidateAddress(field.Field().String()) == nil
+}
@@ -0,0 +1,47 @@
+package validation
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	hex "encoding/hex"
+	testing "testing"
+)
+
+type addressRequest struct {
+	Address  string `validate:"required,address"`
+	Optional string `validate:"omitempty,address"`
+}
+
+func TestAddressTag(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := primitives.PublicKeyToAddress(&key.PublicKey)
+	mistyped := []byte(address)
+	mistyped[len(mistyped)/2] ^= 'a' ^ 'b'
+
+	tests := []struct {
+		name    string
+		request addressRequest
+		valid   bool
+	}{
+		{"address", addressRequest{Address: address}, true},
+		{"optional address", addressRequest{Address: address, Optional: address}, true},
+		{"missing address", addressRequest{}, false},
+		{"mistyped address", addressRequest{Address: string(mistyped)}, false},
+		{"hex public key", addressRequest{Address: hex.EncodeToString(primitives.MarshalPublicKey(&key.PublicKey))}, false},
+		{"other version", addressRequest{Address: primitives.Base58CheckEncode(0x00, make([]byte, primitives.AddressHashLength))}, false},
+		{"invalid optional address", addressRequest{Address: address, Optional: "B"}, false},
+	}
+
+	validator := NewValidator()
+	for _, test := range tests {
+		err := validator.ValidateStruct(test.request)
+		if (err == nil) != test.valid {
+			t.Fatalf("%s: got %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
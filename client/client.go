@@ -0,0 +1,219 @@
+package client
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	bytes "bytes"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	hex "encoding/hex"
+	json "encoding/json"
+	errors "errors"
+	fmt "fmt"
+	io "io"
+	http "net/http"
+	strings "strings"
+	time "time"
+)
+
+const (
+	BuildTransactionPath  = "/api/build-transaction"
+	SubmitTransactionPath = "/api/submit-transaction"
+
+	// DefaultTimeout bounds a request when no HTTP client is given.
+	DefaultTimeout = 10 * time.Second
+)
+
+var (
+	ErrEncodingVersion      = errors.New("the node uses another canonical encoding version")
+	ErrSigningBytesMismatch = errors.New("the node's signing bytes do not match the transaction")
+	ErrUnexpectedFields     = errors.New("the node built a different transaction than requested")
+)
+
+// ResponseError is an error answer of the node.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *ResponseError) Error() string {
+	return fmt.Sprintf("node answered %d: %s", err.StatusCode, err.Message)
+}
+
+// UnsignedTransaction is the node's answer to a build request, the JSON form of its unsigned transaction view.
+type UnsignedTransaction struct {
+	FromAddress     string            `json:"fromAddress"`
+	PublicKey       []byte            `json:"publicKey"`
+	ToAddress       string            `json:"toAddress"`
+	Amount          primitives.Amount `json:"amount"`
+	Fee             primitives.Amount `json:"fee"`
+	MinimumFee      primitives.Amount `json:"minimumFee"`
+	Nonce           uint64            `json:"nonce"`
+	EncodingVersion byte              `json:"encodingVersion"`
+	SigningBytes    string            `json:"signingBytes"`
+	SigningHash     string            `json:"signingHash"`
+}
+
+// buildTransactionRequest and submitTransactionRequest mirror the node's binding models. They are kept here so
+// the client only depends on the primitives package.
+type buildTransactionRequest struct {
+	FromAddress string             `json:"fromAddress"`
+	PublicKey   []byte             `json:"publicKey"`
+	ToAddress   string             `json:"toAddress"`
+	Amount      primitives.Amount  `json:"amount"`
+	Fee         *primitives.Amount `json:"fee,omitempty"`
+}
+
+type submitTransactionRequest struct {
+	FromAddress string            `json:"fromAddress"`
+	PublicKey   []byte            `json:"publicKey"`
+	ToAddress   string            `json:"toAddress"`
+	Amount      primitives.Amount `json:"amount"`
+	Fee         primitives.Amount `json:"fee"`
+	Nonce       uint64            `json:"nonce"`
+	Signature   []byte            `json:"signature"`
+}
+
+// Client talks to a node's transaction endpoints. Transactions are signed locally with the same canonical
+// encoding the node verifies, private keys never leave the calling service.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the node at baseURL. A nil httpClient uses one with DefaultTimeout.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// BuildTransaction asks the node for a transfer from the key's address with the next nonce. A nil fee lets the
+// node pick the minimum relay fee.
+func (client *Client) BuildTransaction(ctx context.Context, publicKey *ecdsa.PublicKey, toAddress string, amount primitives.Amount, fee *primitives.Amount) (UnsignedTransaction, error) {
+	request := buildTransactionRequest{
+		FromAddress: primitives.PublicKeyToAddress(publicKey),
+		PublicKey:   primitives.MarshalPublicKey(publicKey),
+		ToAddress:   toAddress,
+		Amount:      amount,
+		Fee:         fee,
+	}
+
+	unsignedTransaction := UnsignedTransaction{}
+	if err := client.post(ctx, BuildTransactionPath, request, &unsignedTransaction); err != nil {
+		return UnsignedTransaction{}, err
+	}
+	return unsignedTransaction, nil
+}
+
+// SubmitTransaction sends a signed transaction to the node and returns its id.
+func (client *Client) SubmitTransaction(ctx context.Context, transaction primitives.BlockTransaction) (string, error) {
+	request := submitTransactionRequest{
+		FromAddress: transaction.FromAddress,
+		PublicKey:   transaction.PublicKey,
+		ToAddress:   transaction.ToAddress,
+		Amount:      transaction.Amount,
+		Fee:         transaction.Fee,
+		Nonce:       transaction.Nonce,
+		Signature:   transaction.Signature,
+	}
+
+	response := struct {
+		TransactionId string `json:"transactionId"`
+	}{}
+	if err := client.post(ctx, SubmitTransactionPath, request, &response); err != nil {
+		return "", err
+	}
+	return response.TransactionId, nil
+}
+
+// SendTransaction builds a transfer on the node, checks that it is the requested one, signs it locally and
+// submits it.
+func (client *Client) SendTransaction(ctx context.Context, signingKey *ecdsa.PrivateKey, toAddress string, amount primitives.Amount, fee *primitives.Amount) (string, error) {
+	unsignedTransaction, err := client.BuildTransaction(ctx, &signingKey.PublicKey, toAddress, amount, fee)
+	if err != nil {
+		return "", err
+	}
+
+	if unsignedTransaction.ToAddress != toAddress || unsignedTransaction.Amount != amount || (fee != nil && unsignedTransaction.Fee != *fee) {
+		return "", ErrUnexpectedFields
+	}
+
+	transaction, err := SignTransaction(unsignedTransaction, signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	return client.SubmitTransaction(ctx, transaction)
+}
+
+// SignTransaction signs a transaction built by a node. The signing bytes are recomputed from the fields first,
+// so what gets signed is exactly what the fields say, whatever bytes the node sent along.
+func SignTransaction(unsignedTransaction UnsignedTransaction, signingKey *ecdsa.PrivateKey) (primitives.BlockTransaction, error) {
+	if unsignedTransaction.EncodingVersion != primitives.CanonicalEncodingVersion {
+		return primitives.BlockTransaction{}, fmt.Errorf("%w: %d, expected %d", ErrEncodingVersion, unsignedTransaction.EncodingVersion, primitives.CanonicalEncodingVersion)
+	}
+
+	transaction := primitives.NewBlockTransaction(
+		unsignedTransaction.FromAddress,
+		unsignedTransaction.ToAddress,
+		unsignedTransaction.Amount,
+		unsignedTransaction.Fee,
+		unsignedTransaction.Nonce)
+	transaction.PublicKey = unsignedTransaction.PublicKey
+
+	if hex.EncodeToString(transaction.SigningBytes()) != unsignedTransaction.SigningBytes {
+		return primitives.BlockTransaction{}, ErrSigningBytesMismatch
+	}
+
+	if err := transaction.SignTransaction(signingKey); err != nil {
+		return primitives.BlockTransaction{}, err
+	}
+
+	// SignTransaction attaches the key's own public key, it has to be the one the signing bytes cover.
+	if !bytes.Equal(transaction.PublicKey, unsignedTransaction.PublicKey) {
+		return primitives.BlockTransaction{}, ErrSigningBytesMismatch
+	}
+
+	return *transaction, nil
+}
+
+func (client *Client) post(ctx context.Context, path string, body interface{}, result interface{}) error {
+	encodedBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, client.baseURL+path, bytes.NewReader(encodedBody))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		errorResponse := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(responseBody, &errorResponse) != nil || errorResponse.Error == "" {
+			errorResponse.Error = response.Status
+		}
+		return &ResponseError{StatusCode: response.StatusCode, Message: errorResponse.Error}
+	}
+
+	return json.Unmarshal(responseBody, result)
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+	context "context"
+	ecdsa "crypto/ecdsa"
+	elliptic "crypto/elliptic"
+	rand "crypto/rand"
+	hex "encoding/hex"
+	json "encoding/json"
+	errors "errors"
+	http "net/http"
+	httptest "net/http/httptest"
+	testing "testing"
+)
+
+const testFee primitives.Amount = 1000
+
+// testNode answers build and submit requests like a node does. alter, when set, changes the built transaction
+// before it is sent to the client.
+type testNode struct {
+	alter     func(unsignedTransaction *UnsignedTransaction)
+	submitted []primitives.BlockTransaction
+}
+
+func (node *testNode) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	switch request.URL.Path {
+	case BuildTransactionPath:
+		buildRequest := buildTransactionRequest{}
+		if err := json.NewDecoder(request.Body).Decode(&buildRequest); err != nil {
+			writeTestResponse(writer, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		fee := testFee
+		if buildRequest.Fee != nil {
+			fee = *buildRequest.Fee
+		}
+		transaction := primitives.NewBlockTransaction(buildRequest.FromAddress, buildRequest.ToAddress, buildRequest.Amount, fee, 3)
+		transaction.PublicKey = buildRequest.PublicKey
+
+		unsignedTransaction := UnsignedTransaction{
+			FromAddress:     transaction.FromAddress,
+			PublicKey:       transaction.PublicKey,
+			ToAddress:       transaction.ToAddress,
+			Amount:          transaction.Amount,
+			Fee:             transaction.Fee,
+			MinimumFee:      testFee,
+			Nonce:           transaction.Nonce,
+			EncodingVersion: primitives.CanonicalEncodingVersion,
+			SigningBytes:    hex.EncodeToString(transaction.SigningBytes()),
+			SigningHash:     hex.EncodeToString(transaction.CalculateHash()),
+		}
+		if node.alter != nil {
+			node.alter(&unsignedTransaction)
+		}
+		writeTestResponse(writer, http.StatusOK, unsignedTransaction)
+
+	case SubmitTransactionPath:
+		submitRequest := submitTransactionRequest{}
+		if err := json.NewDecoder(request.Body).Decode(&submitRequest); err != nil {
+			writeTestResponse(writer, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		transaction := primitives.NewBlockTransaction(submitRequest.FromAddress, submitRequest.ToAddress, submitRequest.Amount, submitRequest.Fee, submitRequest.Nonce)
+		transaction.PublicKey = submitRequest.PublicKey
+		transaction.Signature = submitRequest.Signature
+		if !transaction.IsValid() {
+			writeTestResponse(writer, http.StatusBadRequest, map[string]string{"error": "transaction signature is not valid"})
+			return
+		}
+
+		node.submitted = append(node.submitted, *transaction)
+		writeTestResponse(writer, http.StatusOK, map[string]string{"transactionId": transaction.TransactionId()})
+
+	default:
+		http.NotFound(writer, request)
+	}
+}
+
+func writeTestResponse(writer http.ResponseWriter, statusCode int, body interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+func newTestClient(t *testing.T, node *testNode) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/", server.Client())
+}
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, primitives.PublicKeyToAddress(&key.PublicKey)
+}
+
+func TestSendTransactionBuildsSignsAndSubmits(t *testing.T) {
+	key, address := newTestKey(t)
+	_, recipient := newTestKey(t)
+	node := &testNode{}
+	client := newTestClient(t, node)
+
+	customFee := 5 * testFee
+	for _, fee := range []*primitives.Amount{nil, &customFee} {
+		transactionId, err := client.SendTransaction(context.Background(), key, recipient, primitives.AmountUnitsPerCoin, fee)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		submitted := node.submitted[len(node.submitted)-1]
+		if transactionId != submitted.TransactionId() {
+			t.Fatalf("got id %s, the node stored %s", transactionId, submitted.TransactionId())
+		}
+
+		expectedFee := testFee
+		if fee != nil {
+			expectedFee = *fee
+		}
+		if submitted.FromAddress != address || submitted.ToAddress != recipient || submitted.Amount != primitives.AmountUnitsPerCoin || submitted.Fee != expectedFee || submitted.Nonce != 3 {
+			t.Fatalf("the node received %+v", submitted)
+		}
+	}
+}
+
+func TestSendTransactionRejectsWhatTheNodeAltered(t *testing.T) {
+	key, _ := newTestKey(t)
+	_, recipient := newTestKey(t)
+	_, attacker := newTestKey(t)
+
+	tests := []struct {
+		name     string
+		alter    func(unsignedTransaction *UnsignedTransaction)
+		expected error
+	}{
+		{"signing bytes of another recipient", func(unsignedTransaction *UnsignedTransaction) {
+			transaction := primitives.NewBlockTransaction(unsignedTransaction.FromAddress, attacker, unsignedTransaction.Amount, unsignedTransaction.Fee, unsignedTransaction.Nonce)
+			transaction.PublicKey = unsignedTransaction.PublicKey
+			unsignedTransaction.SigningBytes = hex.EncodeToString(transaction.SigningBytes())
+		}, ErrSigningBytesMismatch},
+		{"signing bytes with another nonce", func(unsignedTransaction *UnsignedTransaction) {
+			unsignedTransaction.Nonce++
+		}, ErrSigningBytesMismatch},
+		{"another public key", func(unsignedTransaction *UnsignedTransaction) {
+			otherKey, _ := newTestKey(t)
+			unsignedTransaction.PublicKey = primitives.MarshalPublicKey(&otherKey.PublicKey)
+			transaction := primitives.NewBlockTransaction(unsignedTransaction.FromAddress, unsignedTransaction.ToAddress, unsignedTransaction.Amount, unsignedTransaction.Fee, unsignedTransaction.Nonce)
+			transaction.PublicKey = unsignedTransaction.PublicKey
+			unsignedTransaction.SigningBytes = hex.EncodeToString(transaction.SigningBytes())
+		}, ErrSigningBytesMismatch},
+		{"another encoding version", func(unsignedTransaction *UnsignedTransaction) {
+			unsignedTransaction.EncodingVersion = primitives.CanonicalEncodingVersion + 1
+		}, ErrEncodingVersion},
+		{"another recipient", func(unsignedTransaction *UnsignedTransaction) {
+			unsignedTransaction.ToAddress = attacker
+		}, ErrUnexpectedFields},
+		{"another amount", func(unsignedTransaction *UnsignedTransaction) {
+			unsignedTransaction.Amount++
+		}, ErrUnexpectedFields},
+	}
+
+	for _, test := range tests {
+		node := &testNode{alter: test.alter}
+		client := newTestClient(t, node)
+
+		if _, err := client.SendTransaction(context.Background(), key, recipient, primitives.AmountUnitsPerCoin, nil); !errors.Is(err, test.expected) {
+			t.Fatalf("%s: got %v, want %v", test.name, err, test.expected)
+		}
+		if len(node.submitted) != 0 {
+			t.Fatalf("%s: the transaction was submitted", test.name)
+		}
+	}
+}
+
+func TestClientReportsNodeErrors(t *testing.T) {
+	key, _ := newTestKey(t)
+	client := newTestClient(t, &testNode{})
+
+	// The node cannot verify a signature over a transaction it did not build.
+	transaction := primitives.NewBlockTransaction(primitives.PublicKeyToAddress(&key.PublicKey), "recipient", 1, testFee, 0)
+	transaction.PublicKey = primitives.MarshalPublicKey(&key.PublicKey)
+	transaction.Signature = []byte{0x01}
+
+	_, err := client.SubmitTransaction(context.Background(), *transaction)
+	responseError := &ResponseError{}
+	if !errors.As(err, &responseError) || responseError.StatusCode != http.StatusBadRequest || responseError.Message != "transaction signature is not valid" {
+		t.Fatalf("got %v, want the node's error", err)
+	}
+}
@@ -0,0 +1,24 @@
+package bindingmodels
+
+// UnlockKeyBindingModel unlocks a keystore key. Without a timeout the node's default unlock timeout applies.
+type UnlockKeyBindingModel struct {
+	KeyId          string `json:"keyId" validate:"required,uuid"`
+	Passphrase     string `json:"passphrase" validate:"required"`
+	TimeoutSeconds int64  `json:"timeoutSeconds" validate:"min=0"`
+}
+
+type LockKeyBindingModel struct {
+	KeyId string `json:"keyId" validate:"required,uuid"`
+}
+
+// ImportKeyBindingModel moves a hex encoded private key into the keystore.
+type ImportKeyBindingModel struct {
+	PrivateKey string `json:"privateKey" validate:"required,hexadecimal"`
+	Passphrase string `json:"passphrase" validate:"required,min=8"`
+}
+
+// BlockSigningKeyBindingModel selects the keystore key the node mines with.
+type BlockSigningKeyBindingModel struct {
+	KeyId      string `json:"keyId" validate:"required,uuid"`
+	Passphrase string `json:"passphrase" validate:"required"`
+}
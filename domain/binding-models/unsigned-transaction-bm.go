@@ -0,0 +1,15 @@
+package bindingmodels
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+)
+
+// UnsignedTransactionBindingModel asks the node to build a transfer for offline signing. The public key is part
+// of what is signed, the address only holds its hash. Without a fee the minimum relay fee is used.
+type UnsignedTransactionBindingModel struct {
+	FromAddress string             `json:"fromAddress" validate:"required,address"`
+	PublicKey   []byte             `json:"publicKey" validate:"required"`
+	ToAddress   string             `json:"toAddress" validate:"required,address"`
+	Amount      primitives.Amount  `json:"amount"`
+	Fee         *primitives.Amount `json:"fee,omitempty"`
+}
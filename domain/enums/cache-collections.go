@@ -3,7 +3,6 @@ package enums
 type CacheCollections int
 
 const (
-	MinerPrivateKey CacheCollections = iota
+	MinerKeyId CacheCollections = iota
 	NodeMetadata
-	PendingTransactions
 )
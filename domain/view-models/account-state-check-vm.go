@@ -0,0 +1,23 @@
+package viewmodels
+
+// AccountStateVM represents the state of one address.
+type AccountStateVM struct {
+	Address            string `json:"address"`
+	Balance            string `json:"balance"`
+	Nonce              uint64 `json:"nonce"`
+	LastActivityHeight int64  `json:"lastActivityHeight"`
+}
+
+// AccountStateDiffVM represents an address whose stored state differs from the one rebuilt from the chain.
+type AccountStateDiffVM struct {
+	Address  string          `json:"address"`
+	Expected *AccountStateVM `json:"expected"`
+	Stored   *AccountStateVM `json:"stored"`
+}
+
+// AccountStateCheckVM represents the result of an account state consistency check.
+type AccountStateCheckVM struct {
+	Consistent  bool                 `json:"consistent"`
+	Repaired    bool                 `json:"repaired"`
+	Differences []AccountStateDiffVM `json:"differences"`
+}
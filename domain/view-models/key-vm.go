@@ -0,0 +1,7 @@
+package viewmodels
+
+// KeyVM represents a key held by the node's keystore.
+type KeyVM struct {
+	KeyId   string `json:"keyId"`
+	Address string `json:"address"`
+}
@@ -0,0 +1,20 @@
+package viewmodels
+
+import (
+	time "time"
+)
+
+// MiningStatusVM represents the state of the node's mining background service.
+type MiningStatusVM struct {
+	Running       bool      `json:"running"`
+	MinerAddress  string    `json:"minerAddress,omitempty"`
+	Height        int64     `json:"height"`
+	Transactions  int       `json:"transactions"`
+	Hashes        uint64    `json:"hashes"`
+	HashRate      float64   `json:"hashRate"`
+	Restarts      uint64    `json:"restarts"`
+	BlocksMined   uint64    `json:"blocksMined"`
+	LastBlockHash string    `json:"lastBlockHash,omitempty"`
+	StartedAt     time.Time `json:"startedAt,omitempty"`
+	LastError     string    `json:"lastError,omitempty"`
+}
@@ -0,0 +1,10 @@
+package viewmodels
+
+// SupplyVM represents the emission state of the chain at its tip.
+type SupplyVM struct {
+	Height            int64  `json:"height"`
+	IssuedSupply      string `json:"issuedSupply"`
+	MaxSupply         string `json:"maxSupply,omitempty"`
+	CurrentReward     string `json:"currentReward"`
+	NextHalvingHeight int64  `json:"nextHalvingHeight,omitempty"`
+}
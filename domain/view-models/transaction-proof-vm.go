@@ -0,0 +1,17 @@
+package viewmodels
+
+// MerkleProofStepVM represents one sibling hash on the path to the merkle root.
+type MerkleProofStepVM struct {
+	Hash   string `json:"hash"`
+	IsLeft bool   `json:"isLeft"`
+}
+
+// TransactionProofVM represents the inclusion proof of a transaction in a block.
+type TransactionProofVM struct {
+	TransactionId string              `json:"transactionId"`
+	BlockHash     string              `json:"blockHash"`
+	BlockHeight   int64               `json:"blockHeight"`
+	MerkleRoot    string              `json:"merkleRoot"`
+	Index         int                 `json:"index"`
+	Steps         []MerkleProofStepVM `json:"steps"`
+}
@@ -0,0 +1,21 @@
+package viewmodels
+
+import (
+	primitives "bitshare-chain/infrastructure/primitives"
+)
+
+// UnsignedTransactionVM represents a transfer ready to be signed offline. SigningBytes is the hex encoded
+// canonical encoding the signature covers and SigningHash its SHA-256, the hash a signer signs. The signed
+// transaction is submitted with the same fields plus the signature.
+type UnsignedTransactionVM struct {
+	FromAddress     string            `json:"fromAddress"`
+	PublicKey       []byte            `json:"publicKey"`
+	ToAddress       string            `json:"toAddress"`
+	Amount          primitives.Amount `json:"amount"`
+	Fee             primitives.Amount `json:"fee"`
+	MinimumFee      primitives.Amount `json:"minimumFee"`
+	Nonce           uint64            `json:"nonce"`
+	EncodingVersion byte              `json:"encodingVersion"`
+	SigningBytes    string            `json:"signingBytes"`
+	SigningHash     string            `json:"signingHash"`
+}
@@ -0,0 +1,18 @@
+package viewmodels
+
+// WalletAccountVM represents an HD wallet account. The mnemonic is only returned when the wallet is created, it
+// is the one backup of every address of the wallet.
+type WalletAccountVM struct {
+	WalletId    string            `json:"walletId"`
+	Mnemonic    string            `json:"mnemonic,omitempty"`
+	AccountPath string            `json:"accountPath,omitempty"`
+	Address     string            `json:"address"`
+	Addresses   []WalletAddressVM `json:"addresses"`
+}
+
+type WalletAddressVM struct {
+	Index   uint32 `json:"index"`
+	Path    string `json:"path"`
+	Address string `json:"address"`
+	KeyId   string `json:"keyId"`
+}
@@ -1,17 +1,19 @@
 package viewmodels
 
-// WalletKeysVM represents the view model for wallet keys.
+// WalletKeysVM represents the view model for wallet keys. The private key stays in the keystore under KeyId.
 type WalletKeysVM struct {
-	PublicKey  string `json:"publicKey"`
-	PrivateKey string `json:"privateKey"`
-	NodeId     string `json:"nodeId"`
+	KeyId     string `json:"keyId"`
+	PublicKey string `json:"publicKey"`
+	Address   string `json:"address"`
+	NodeId    string `json:"nodeId,omitempty"`
 }
 
 // NewWalletKeysVM creates a new instance of WalletKeysVM.
-func NewWalletKeysVM(publicKey, privateKey, nodeId string) WalletKeysVM {
+func NewWalletKeysVM(keyId, publicKey, address, nodeId string) WalletKeysVM {
 	return WalletKeysVM{
-		PublicKey:  publicKey,
-		PrivateKey: privateKey,
-		NodeId:     nodeId,
+		KeyId:     keyId,
+		PublicKey: publicKey,
+		Address:   address,
+		NodeId:    nodeId,
 	}
 }
@@ -2,59 +2,103 @@ package controllers
 
 import (
 	commands "bitshare-chain/application/commands"
+	repositories "bitshare-chain/application/data-access/repositories"
+	mappers "bitshare-chain/application/mappers"
 	services "bitshare-chain/application/services"
+	background_services "bitshare-chain/application/services/background-services"
+	validation "bitshare-chain/application/validation"
 	bindingmodels "bitshare-chain/domain/binding-models"
-	enums "bitshare-chain/domain/enums"
-	ecdsa "crypto/ecdsa"
-	elliptic "crypto/elliptic"
-	hex "encoding/hex"
+	mempool "bitshare-chain/infrastructure/mempool"
+	primitives "bitshare-chain/infrastructure/primitives"
+	utilities "bitshare-chain/infrastructure/utilities"
 	errors "errors"
-	big "math/big"
+	io "io"
 	http "net/http"
-	sync "sync"
 
 	gin "github.com/gin-gonic/gin"
 )
 
 type ChainController struct {
-	cache                             sync.Map
-	ginRouter                         *gin.Engine
-	createWalletAccountCommandHandler *commands.CreateWalletAccountCommandHandler
-	metadataService                   *services.MetadataService
+	ginRouter                          *gin.Engine
+	createWalletAccountCommandHandler  *commands.CreateWalletAccountCommandHandler
+	recoverWalletAccountCommandHandler *commands.RecoverWalletAccountCommandHandler
+	deriveWalletAddressCommandHandler  *commands.DeriveWalletAddressCommandHandler
+	checkAccountStateCommandHandler    *commands.CheckAccountStateCommandHandler
+	metadataService                    *services.MetadataService
+	keystoreService                    *services.KeystoreService
+	blockchainService                  *services.BlockchainService
+	miningBackgroundService            *background_services.MiningBackgroundService
+	validator                          *validation.Validator
 }
 
 type ChainControllerer interface {
 	SetupChainController()
 	SetBlockSigningKeys(context *gin.Context)
 	RequestTransaction(context *gin.Context)
-	// GetPendingTransaction(context *gin.Context)
+	BuildTransaction(context *gin.Context)
+	SubmitTransaction(context *gin.Context)
+	GetPendingTransaction(context *gin.Context)
+	GetNextNonce(context *gin.Context)
+	CheckAccountState(context *gin.Context)
+	GetTransactionProof(context *gin.Context)
+	GetSupply(context *gin.Context)
+	ReceiveBlock(context *gin.Context)
 	// MineTransactions(context *gin.Context)
 	// GetBalanceOfAddress(context *gin.Context)
 	CreateNewWalletAccount(context *gin.Context)
+	RecoverWalletAccount(context *gin.Context)
+	DeriveWalletAddress(context *gin.Context)
 	// IsTheChainValid()
 }
 
 func NewChainController(
 	ginRouter *gin.Engine,
 	createWalletAccountCommandHandler *commands.CreateWalletAccountCommandHandler,
-	metadataService *services.MetadataService) ChainControllerer {
+	recoverWalletAccountCommandHandler *commands.RecoverWalletAccountCommandHandler,
+	deriveWalletAddressCommandHandler *commands.DeriveWalletAddressCommandHandler,
+	checkAccountStateCommandHandler *commands.CheckAccountStateCommandHandler,
+	metadataService *services.MetadataService,
+	keystoreService *services.KeystoreService,
+	blockchainService *services.BlockchainService,
+	miningBackgroundService *background_services.MiningBackgroundService,
+	validator *validation.Validator) ChainControllerer {
 	return &ChainController{
-		ginRouter:                         ginRouter,
-		createWalletAccountCommandHandler: createWalletAccountCommandHandler,
-		metadataService:                   metadataService,
+		ginRouter:                          ginRouter,
+		createWalletAccountCommandHandler:  createWalletAccountCommandHandler,
+		recoverWalletAccountCommandHandler: recoverWalletAccountCommandHandler,
+		deriveWalletAddressCommandHandler:  deriveWalletAddressCommandHandler,
+		checkAccountStateCommandHandler:    checkAccountStateCommandHandler,
+		metadataService:                    metadataService,
+		keystoreService:                    keystoreService,
+		blockchainService:                  blockchainService,
+		miningBackgroundService:            miningBackgroundService,
+		validator:                          validator,
 	}
 }
 
 func (controller *ChainController) SetupChainController() {
 	controller.ginRouter.POST("/api/create-wallet", controller.CreateNewWalletAccount)
+	controller.ginRouter.POST("/api/recover-wallet", controller.RecoverWalletAccount)
+	controller.ginRouter.POST("/api/derive-wallet-address", controller.DeriveWalletAddress)
 	controller.ginRouter.POST("/api/set-block-signing-keys", controller.SetBlockSigningKeys)
 	controller.ginRouter.POST("/api/request-transaction", controller.RequestTransaction)
+	controller.ginRouter.POST("/api/build-transaction", controller.BuildTransaction)
+	controller.ginRouter.POST("/api/submit-transaction", controller.SubmitTransaction)
 	controller.ginRouter.POST("/api/get-pending-transaction", controller.GetPendingTransaction)
+	controller.ginRouter.GET("/api/get-next-nonce", controller.GetNextNonce)
+	controller.ginRouter.POST("/api/check-account-state", controller.CheckAccountState)
+	controller.ginRouter.GET("/api/get-transaction-proof", controller.GetTransactionProof)
+	controller.ginRouter.GET("/api/get-supply", controller.GetSupply)
+	controller.ginRouter.POST(services.ReceiveBlockPath, controller.ReceiveBlock)
 }
 
 // "POST" "api/create-wallet"
 func (controller *ChainController) CreateNewWalletAccount(context *gin.Context) {
 	var createWalletAccountCommand commands.CreateWalletAccountCommand
+	if err := context.BindJSON(&createWalletAccountCommand); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
 
 	response, err := controller.createWalletAccountCommandHandler.Handle(context.Request.Context(), createWalletAccountCommand)
 	if err != nil {
@@ -71,17 +115,80 @@ func (controller *ChainController) CreateNewWalletAccount(context *gin.Context)
 	context.Data(200, "application/json; charset=utf-8", responseBytes)
 }
 
+// "POST" "/api/recover-wallet"
+func (controller *ChainController) RecoverWalletAccount(context *gin.Context) {
+	var recoverWalletAccountCommand commands.RecoverWalletAccountCommand
+	if err := context.BindJSON(&recoverWalletAccountCommand); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	walletAccount, err := controller.recoverWalletAccountCommandHandler.Handle(context.Request.Context(), recoverWalletAccountCommand)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, walletAccount)
+}
+
+// "POST" "/api/derive-wallet-address"
+func (controller *ChainController) DeriveWalletAddress(context *gin.Context) {
+	var deriveWalletAddressCommand commands.DeriveWalletAddressCommand
+	if err := context.BindJSON(&deriveWalletAddressCommand); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	walletAccount, err := controller.deriveWalletAddressCommandHandler.Handle(context.Request.Context(), deriveWalletAddressCommand)
+	if err != nil {
+		status := keystoreErrorStatus(err)
+		switch {
+		case errors.Is(err, commands.ErrWalletAccountNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, repositories.ErrWalletAddressIndexTaken):
+			status = http.StatusConflict
+		case status == http.StatusInternalServerError:
+			status = http.StatusBadRequest
+		}
+
+		context.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, walletAccount)
+}
+
 // "POST" "api/set-block-signing-keys"
 func (controller *ChainController) SetBlockSigningKeys(context *gin.Context) {
-	privateKey := context.Query("privateKey")
-	if privateKey == "" {
-		context.IndentedJSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+	var blockSigningKeyBM bindingmodels.BlockSigningKeyBindingModel
+	if err := context.BindJSON(&blockSigningKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := controller.validator.ValidateStruct(blockSigningKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	keys, err := controller.metadataService.CreateOrUpdateKeys(privateKey)
+	keys, err := controller.metadataService.SetBlockSigningKey(blockSigningKeyBM.KeyId, blockSigningKeyBM.Passphrase)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, err.Error())
+		context.JSON(keystoreErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// New keys (re)start the miner, so the rewards always go to the current reward address.
+	// The key may have been locked or replaced by another request since it was set.
+	signingKey, ok := controller.metadataService.GetBlockSigningKey()
+	if !ok {
+		context.JSON(http.StatusConflict, gin.H{"error": background_services.ErrNoSigningKey.Error()})
+		return
+	}
+
+	if err := controller.miningBackgroundService.Restart(signingKey); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
 	context.JSON(http.StatusOK, keys)
@@ -89,10 +196,12 @@ func (controller *ChainController) SetBlockSigningKeys(context *gin.Context) {
 
 // "POST" "/api/request-transaction"
 func (controller *ChainController) RequestTransaction(context *gin.Context) {
-
-	// !!! Temporary !!!
-	// Could also be signed in the front-end end sent to the back-end (probably more secured way since the private key is not traveling in the network)
-	// !!! Temporary !!!
+	// The transaction is signed with a key of the node's keystore, which has to be unlocked first.
+	keyId := context.Query("keyId")
+	if keyId == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Key id is required"})
+		return
+	}
 
 	var transactionBM bindingmodels.TransactionBindingModel
 	if err := context.BindJSON(&transactionBM); err != nil {
@@ -100,62 +209,183 @@ func (controller *ChainController) RequestTransaction(context *gin.Context) {
 		return
 	}
 
-	privateKey := context.Query("privateKey")
-	privateKeyBytes, err := hex.DecodeString(privateKey)
-	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private key"})
+	if err := controller.validator.ValidateStruct(transactionBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	privateKeyECDSA, err := parsePrivateKey(privateKeyBytes)
-	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate keys"})
+	transaction := mappers.FromTransactionBindingModel(transactionBM)
+	if err := controller.keystoreService.SignTransaction(keyId, &transaction); err != nil {
+		context.JSON(keystoreErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := transactionBM.SignTransaction(privateKeyECDSA); err != nil {
+	if err := controller.blockchainService.AddTransaction(transaction); err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	controller.cache.Store(enums.PendingTransactions, transactionBM)
-
 	context.JSON(http.StatusOK, gin.H{"message": "Transaction signed successfully"})
 }
 
-func parsePrivateKey(privateKeyBytes []byte) (*ecdsa.PrivateKey, error) {
-	curve := elliptic.P256()
-	privateKey := new(ecdsa.PrivateKey)
-	privateKey.Curve = curve
-	privateKey.D = new(big.Int).SetBytes(privateKeyBytes)
-	privateKey.PublicKey.Curve = curve
-	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(privateKeyBytes)
-	if privateKey.PublicKey.X == nil || privateKey.PublicKey.Y == nil {
-		return nil, errors.New("invalid private key")
+// "POST" "/api/build-transaction"
+func (controller *ChainController) BuildTransaction(context *gin.Context) {
+	var unsignedTransactionBM bindingmodels.UnsignedTransactionBindingModel
+	if err := context.BindJSON(&unsignedTransactionBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := controller.validator.ValidateStruct(unsignedTransactionBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unsignedTransaction, err := controller.blockchainService.BuildTransaction(
+		unsignedTransactionBM.FromAddress,
+		unsignedTransactionBM.PublicKey,
+		unsignedTransactionBM.ToAddress,
+		unsignedTransactionBM.Amount,
+		unsignedTransactionBM.Fee)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, unsignedTransaction)
+}
+
+// "POST" "/api/submit-transaction"
+func (controller *ChainController) SubmitTransaction(context *gin.Context) {
+	var transactionBM bindingmodels.TransactionBindingModel
+	if err := context.BindJSON(&transactionBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := controller.validator.ValidateStruct(transactionBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transactionId, err := controller.blockchainService.SubmitTransaction(mappers.FromTransactionBindingModel(transactionBM))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, mempool.ErrAlreadyKnown) {
+			status = http.StatusConflict
+		}
+
+		context.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
-	return privateKey, nil
+
+	context.JSON(http.StatusOK, gin.H{"transactionId": transactionId})
 }
 
 // "POST" "/api/get-pending-transaction"
 func (controller *ChainController) GetPendingTransaction(context *gin.Context) {
-	var pendingTransactions []bindingmodels.TransactionBindingModel
+	pendingTransactions := []bindingmodels.TransactionBindingModel{}
+	for _, transaction := range controller.blockchainService.GetPendingTransactions() {
+		pendingTransactions = append(pendingTransactions, mappers.ToTransactionBindingModel(transaction))
+	}
 
-	values, ok := controller.cache.Load(enums.PendingTransactions)
-	if !ok {
-		context.JSON(http.StatusOK, values)
-	} else {
-		context.JSON(http.StatusNotFound, "")
+	context.JSON(http.StatusOK, pendingTransactions)
+}
+
+// "GET" "/api/get-next-nonce"
+func (controller *ChainController) GetNextNonce(context *gin.Context) {
+	address := context.Query("address")
+	if err := primitives.ValidateAddress(address); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Iterate over all entries in the cache
-	controller.cache.Range(func(key, value interface{}) bool {
-		// Check if the value is of type TransactionBindingModel
-		if transaction, ok := value.(bindingmodels.TransactionBindingModel); ok {
-			// Append the transaction to the pendingTransactions slice
-			pendingTransactions = append(pendingTransactions, transaction)
-		}
-		return true // Continue iterating
+	context.JSON(http.StatusOK, gin.H{
+		"address":   address,
+		"nextNonce": controller.blockchainService.GetNextNonce(address),
 	})
+}
 
-	context.JSON(http.StatusOK, pendingTransactions)
+// "POST" "/api/check-account-state"
+func (controller *ChainController) CheckAccountState(context *gin.Context) {
+	var checkAccountStateCommand commands.CheckAccountStateCommand
+	if err := context.ShouldBindJSON(&checkAccountStateCommand); err != nil && err != io.EOF {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	result, err := controller.checkAccountStateCommandHandler.Handle(context.Request.Context(), checkAccountStateCommand)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, result)
+}
+
+// "GET" "/api/get-transaction-proof"
+func (controller *ChainController) GetTransactionProof(context *gin.Context) {
+	transactionId := context.Query("transactionId")
+	if transactionId == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Transaction id is required"})
+		return
+	}
+
+	proof, err := controller.blockchainService.GetTransactionProof(transactionId)
+	if errors.Is(err, services.ErrTransactionNotFound) {
+		context.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, proof)
+}
+
+// "GET" "/api/get-supply"
+func (controller *ChainController) GetSupply(context *gin.Context) {
+	supply, err := controller.blockchainService.GetSupply()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, supply)
+}
+
+// "POST" "/api/receive-block"
+func (controller *ChainController) ReceiveBlock(context *gin.Context) {
+	context.Request.Body = http.MaxBytesReader(context.Writer, context.Request.Body, utilities.MaxBlockSize)
+	encodedBlock, err := context.GetRawData()
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Block exceeds the maximum block size"})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	block, err := utilities.UnmarshalCanonicalBlock(encodedBlock)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := controller.blockchainService.AddBlock(block); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, utilities.ErrBlockAlreadyKnown) {
+			status = http.StatusConflict
+		}
+
+		context.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"message": "Block accepted"})
 }
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	utilities "bitshare-chain/infrastructure/utilities"
+	bytes "bytes"
+	http "net/http"
+	httptest "net/http/httptest"
+	testing "testing"
+
+	gin "github.com/gin-gonic/gin"
+)
+
+func TestReceiveBlockRejectsOversizedBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := &ChainController{ginRouter: router}
+	router.POST("/api/receive-block", controller.ReceiveBlock)
+
+	tests := []struct {
+		name   string
+		size   int
+		status int
+	}{
+		{"over the limit", utilities.MaxBlockSize + 1, http.StatusRequestEntityTooLarge},
+		{"at the limit", utilities.MaxBlockSize, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/api/receive-block", bytes.NewReader(make([]byte, test.size)))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: got status %d, want %d", test.name, recorder.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	services "bitshare-chain/application/services"
+	validation "bitshare-chain/application/validation"
+	bindingmodels "bitshare-chain/domain/binding-models"
+	keystore "bitshare-chain/infrastructure/keystore"
+	errors "errors"
+	http "net/http"
+	time "time"
+
+	gin "github.com/gin-gonic/gin"
+)
+
+type KeystoreController struct {
+	ginRouter       *gin.Engine
+	keystoreService *services.KeystoreService
+	validator       *validation.Validator
+}
+
+type KeystoreControllerer interface {
+	SetupKeystoreController()
+	ImportKey(context *gin.Context)
+	UnlockKey(context *gin.Context)
+	LockKey(context *gin.Context)
+	GetKeys(context *gin.Context)
+}
+
+func NewKeystoreController(
+	ginRouter *gin.Engine,
+	keystoreService *services.KeystoreService,
+	validator *validation.Validator) KeystoreControllerer {
+	return &KeystoreController{
+		ginRouter:       ginRouter,
+		keystoreService: keystoreService,
+		validator:       validator,
+	}
+}
+
+func (controller *KeystoreController) SetupKeystoreController() {
+	controller.ginRouter.POST("/api/import-key", controller.ImportKey)
+	controller.ginRouter.POST("/api/unlock-key", controller.UnlockKey)
+	controller.ginRouter.POST("/api/lock-key", controller.LockKey)
+	controller.ginRouter.GET("/api/get-keys", controller.GetKeys)
+}
+
+// "POST" "/api/import-key"
+func (controller *KeystoreController) ImportKey(context *gin.Context) {
+	var importKeyBM bindingmodels.ImportKeyBindingModel
+	if err := context.BindJSON(&importKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := controller.validator.ValidateStruct(importKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := controller.keystoreService.ImportKey(importKeyBM.PrivateKey, importKeyBM.Passphrase)
+	if err != nil {
+		context.JSON(keystoreErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, key)
+}
+
+// "POST" "/api/unlock-key"
+func (controller *KeystoreController) UnlockKey(context *gin.Context) {
+	var unlockKeyBM bindingmodels.UnlockKeyBindingModel
+	if err := context.BindJSON(&unlockKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := controller.validator.ValidateStruct(unlockKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	timeout := time.Duration(unlockKeyBM.TimeoutSeconds) * time.Second
+	key, err := controller.keystoreService.UnlockKey(unlockKeyBM.KeyId, unlockKeyBM.Passphrase, timeout)
+	if err != nil {
+		context.JSON(keystoreErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, key)
+}
+
+// "POST" "/api/lock-key"
+func (controller *KeystoreController) LockKey(context *gin.Context) {
+	var lockKeyBM bindingmodels.LockKeyBindingModel
+	if err := context.BindJSON(&lockKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if err := controller.validator.ValidateStruct(lockKeyBM); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	controller.keystoreService.LockKey(lockKeyBM.KeyId)
+	context.JSON(http.StatusOK, gin.H{"message": "Key locked"})
+}
+
+// "GET" "/api/get-keys"
+func (controller *KeystoreController) GetKeys(context *gin.Context) {
+	keys, err := controller.keystoreService.GetKeys()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, keys)
+}
+
+// keystoreErrorStatus maps keystore errors to the status of the response.
+func keystoreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, keystore.ErrKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, keystore.ErrInvalidPassphrase), errors.Is(err, keystore.ErrKeyLocked):
+		return http.StatusUnauthorized
+	case errors.Is(err, keystore.ErrKeyMismatch), errors.Is(err, keystore.ErrInvalidPrivateKey):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
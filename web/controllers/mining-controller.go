@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	services "bitshare-chain/application/services"
+	background_services "bitshare-chain/application/services/background-services"
+	errors "errors"
+	http "net/http"
+
+	gin "github.com/gin-gonic/gin"
+)
+
+type MiningController struct {
+	ginRouter               *gin.Engine
+	metadataService         *services.MetadataService
+	miningBackgroundService *background_services.MiningBackgroundService
+}
+
+type MiningControllerer interface {
+	SetupMiningController()
+	StartMining(context *gin.Context)
+	StopMining(context *gin.Context)
+	GetMiningStatus(context *gin.Context)
+}
+
+func NewMiningController(
+	ginRouter *gin.Engine,
+	metadataService *services.MetadataService,
+	miningBackgroundService *background_services.MiningBackgroundService) MiningControllerer {
+	return &MiningController{
+		ginRouter:               ginRouter,
+		metadataService:         metadataService,
+		miningBackgroundService: miningBackgroundService,
+	}
+}
+
+func (controller *MiningController) SetupMiningController() {
+	controller.ginRouter.POST("/api/start-mining", controller.StartMining)
+	controller.ginRouter.POST("/api/stop-mining", controller.StopMining)
+	controller.ginRouter.GET("/api/get-mining-status", controller.GetMiningStatus)
+}
+
+// "POST" "/api/start-mining"
+func (controller *MiningController) StartMining(context *gin.Context) {
+	signingKey, ok := controller.metadataService.GetBlockSigningKey()
+	if !ok {
+		context.JSON(http.StatusBadRequest, gin.H{"error": "Block signing keys are not set"})
+		return
+	}
+
+	if err := controller.miningBackgroundService.Start(signingKey); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, background_services.ErrMiningAlreadyRunning) {
+			status = http.StatusConflict
+		}
+
+		context.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, controller.miningBackgroundService.Status())
+}
+
+// "POST" "/api/stop-mining"
+func (controller *MiningController) StopMining(context *gin.Context) {
+	if err := controller.miningBackgroundService.Shutdown(context.Request.Context()); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	context.JSON(http.StatusOK, controller.miningBackgroundService.Status())
+}
+
+// "GET" "/api/get-mining-status"
+func (controller *MiningController) GetMiningStatus(context *gin.Context) {
+	context.JSON(http.StatusOK, controller.miningBackgroundService.Status())
+}